@@ -0,0 +1,182 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/bossm8/portfoli.go/config"
+	"github.com/bossm8/portfoli.go/models/content"
+	modelutils "github.com/bossm8/portfoli.go/models/utils"
+	"github.com/bossm8/portfoli.go/server"
+	"github.com/bossm8/portfoli.go/static"
+	"github.com/bossm8/portfoli.go/utils"
+)
+
+// version is set on build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// runServe starts the portfolio server
+func runServe(args []string) error {
+	fs := newFlagSet("serve", "Serve the portfolio dynamically, including the contact form.")
+	paths := addPathFlags(fs)
+	basePath := addBasePathFlag(fs)
+	srv := addServeFlags(fs)
+	strict := addStrictFlag(fs, false)
+	logs := addLogFlags(fs)
+	fs.Parse(args)
+
+	logs.setup(fs)
+	modelutils.SetStrict(*strict)
+	serve(paths, *basePath, srv)
+	return nil
+}
+
+// serve starts the server with the options passed
+func serve(paths *pathFlags, basePath string, srv *serveFlags) {
+	utils.SetDevMode(*srv.dev)
+	if *srv.drafts {
+		slog.Warn("Rendering unpublished cards, do not expose this server publicly")
+		content.SetPreviewDrafts(true)
+	}
+
+	configDir := config.ConvertToAbsPath(paths.configDir)
+	slog.Info("Using config path", "path", configDir)
+
+	// the development mode requires watching for the live reload
+	watchInterval := *srv.watchInterval
+	if !*srv.watch && !*srv.dev {
+		watchInterval = 0
+	}
+	// Do not log the dist dir path by using nil
+	config.SetPaths(paths.templatesDir, paths.staticDir, nil)
+	server.StartServer(
+		fmt.Sprintf("%s:%d", *srv.addr, *srv.port),
+		basePath,
+		configDir,
+		*paths.imageCacheDir,
+		srv.timeouts(),
+		srv.tlsOptions(),
+		watchInterval,
+	)
+}
+
+// addDistDirFlag adds the flag for the output directory of the static build
+func addDistDirFlag(fs *flag.FlagSet) *string {
+	return fs.String(
+		"dist.dir",
+		config.DistDir(),
+		"Path to the directory of where to output the static build",
+	)
+}
+
+// runBuild creates the static website
+func runBuild(args []string) error {
+	fs := newFlagSet("build", "Create a static website build to e.g. host on GitLab pages.\n"+
+		"The content of the static dir must be copied to <dist.dir>/static afterwards.")
+	paths := addPathFlags(fs)
+	basePath := addBasePathFlag(fs)
+	distDir := addDistDirFlag(fs)
+	strict := addStrictFlag(fs, false)
+	logs := addLogFlags(fs)
+	fs.Parse(args)
+
+	logs.setup(fs)
+	modelutils.SetStrict(*strict)
+	build(paths, *basePath, distDir)
+	return nil
+}
+
+// build creates the static website with the options passed
+func build(paths *pathFlags, basePath string, distDir *string) {
+	configDir := config.ConvertToAbsPath(paths.configDir)
+	slog.Info("Using config path", "path", configDir)
+
+	config.SetPaths(paths.templatesDir, paths.staticDir, distDir)
+	static.Build(basePath, configDir, *paths.imageCacheDir)
+}
+
+// runVersion prints the version and build information
+func runVersion(args []string) error {
+	fs := newFlagSet("version", "Print the version information.")
+	fs.Parse(args)
+
+	revision := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+	fmt.Printf("%s %s (revision %s, %s %s/%s)\n",
+		programName(), version, revision, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+// runLegacy runs the server or the static build with the flags of the
+// versions before the subcommands were introduced
+func runLegacy(args []string) error {
+	fs := flag.NewFlagSet(programName(), flag.ExitOnError)
+	fs.Usage = func() {
+		usage(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nDeprecated flags when running without a command:\n")
+		fs.PrintDefaults()
+	}
+	paths := addPathFlags(fs)
+	basePath := addBasePathFlag(fs)
+	srv := addServeFlags(fs)
+	distDir := addDistDirFlag(fs)
+	logs := addLogFlags(fs)
+	dist := fs.Bool(
+		"dist",
+		false,
+		"Create a static website build to e.g. host on GitLab pages (deprecated, use the build command)",
+	)
+	fs.Parse(args)
+
+	logs.setup(fs)
+
+	if *dist {
+		slog.Warn("The -dist flag is deprecated, use the build command instead",
+			"example", programName()+" build -dist.dir <dir>")
+		build(paths, *basePath, distDir)
+		return nil
+	}
+	if len(args) > 0 {
+		slog.Warn("Running without a command is deprecated, use the serve command instead",
+			"example", programName()+" serve -srv.address <addr>")
+	}
+	serve(paths, *basePath, srv)
+	return nil
+}
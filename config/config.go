@@ -31,9 +31,11 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"regexp"
+
+	"github.com/bossm8/portfoli.go/logging"
 )
 
 const (
@@ -41,6 +43,10 @@ const (
 	BaseTemplateName = "base"
 	// ContentTemplateName holds the name of the template containing all content templates
 	ContentTemplateName = "content"
+	// DetailTemplateName holds the name of the template showing a single card
+	DetailTemplateName = "detail"
+	// TagsTemplateName holds the name of the template listing all tags
+	TagsTemplateName = "tags"
 	// StatusTemplateName holds the name of the template showing status meessages
 	StatusTemplateName = "status"
 	// ContanctTemplateName holds the name of the template with the contact form
@@ -62,7 +68,7 @@ var (
 func ConvertToAbsPath(path *string) string {
 	abs, err := filepath.Abs(*path)
 	if err != nil {
-		log.Fatalf("[ERROR] parsing configuration directory: %s\n", err)
+		logging.Fatal("Parsing configuration directory failed", "path", *path, "error", err)
 	}
 	return abs
 }
@@ -73,15 +79,15 @@ func ConvertToAbsPath(path *string) string {
 func SetPaths(templates *string, static *string, dist *string) {
 	if nil != templates {
 		templatesDir = ConvertToAbsPath(templates)
-		log.Printf("[INFO] using templates path %s\n", templatesDir)
+		slog.Info("Using templates path", "path", templatesDir)
 	}
 	if nil != static {
 		staticDir = ConvertToAbsPath(static)
-		log.Printf("[INFO] using static path %s\n", staticDir)
+		slog.Info("Using static path", "path", staticDir)
 	}
 	if nil != dist {
 		distDir = ConvertToAbsPath(dist)
-		log.Printf("[INFO] using dist path %s\n", distDir)
+		slog.Info("Using dist path", "path", distDir)
 	}
 }
 
@@ -89,9 +95,11 @@ func SetPaths(templates *string, static *string, dist *string) {
 // which cannot be rendered on their own when building the static website
 func StaticIgnoreRegex() *regexp.Regexp {
 	return regexp.MustCompile(
-		fmt.Sprintf("(%s|%s|%s|%s)",
+		fmt.Sprintf("(%s|%s|%s|%s|%s|%s)",
 			BaseTemplateName,
 			ContentTemplateName,
+			DetailTemplateName,
+			TagsTemplateName,
 			StatusTemplateName,
 			ContactTemplateName,
 		),
@@ -16,4 +16,4 @@ STATIC_PATH=/var/www/portfoli.go/public
 
 cp -rp ${STATIC_PATH} ${DIST_PATH}/static
 mv ${DIST_PATH}/static/favicon.ico ${DIST_PATH}
-portfoli-go -dist -dist.dir ${DIST_PATH} -config.dir ${CONF_PATH} -srv.base ${SRV_BASE_PATH:-"/"}
\ No newline at end of file
+portfoli-go build -dist.dir ${DIST_PATH} -config.dir ${CONF_PATH} -srv.base ${SRV_BASE_PATH:-"/"}
\ No newline at end of file
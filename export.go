@@ -0,0 +1,102 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/bossm8/portfoli.go/config"
+	"github.com/bossm8/portfoli.go/models"
+	modelconfig "github.com/bossm8/portfoli.go/models/config"
+	"github.com/bossm8/portfoli.go/models/content"
+)
+
+// exportData is the document written by the export command, the smtp
+// configuration is left out on purpose
+type exportData struct {
+	Profile *modelconfig.ProfileConfig `json:"profile"`
+	// Content contains the content of all enabled types by their name
+	Content map[string]content.ContentConfig `json:"content"`
+}
+
+// runExport writes the profile and the published content of all enabled
+// content types as JSON, e.g. to reuse it in other tools
+func runExport(args []string) error {
+	fs := newFlagSet("export", "Export the profile and content as JSON.")
+	configDir := addConfigDirFlag(fs)
+	output := fs.String(
+		"o",
+		"",
+		"Path of the file to write the JSON to (default: stdout)",
+	)
+	drafts := fs.Bool(
+		"drafts",
+		false,
+		"Export the cards which are not published (drafts, scheduled and expired ones) as well",
+	)
+	logs := addLogFlags(fs)
+	fs.Parse(args)
+
+	logs.setup(fs)
+	content.SetPreviewDrafts(*drafts)
+
+	cfg, err := models.LoadConfiguration(config.ConvertToAbsPath(configDir))
+	if err != nil && !errors.Is(err, modelconfig.ErrInvalidSMTPConfig) {
+		return err
+	}
+
+	data := &exportData{
+		Profile: cfg.Profile,
+		Content: make(map[string]content.ContentConfig, len(cfg.Profile.ContentTypes)),
+	}
+	for _, contentType := range cfg.Profile.ContentTypes {
+		if data.Content[contentType], err = content.LoadPublished(contentType); err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+		slog.Info("Exporting content", "output", *output)
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(data)
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bossm8/portfoli.go/config"
+	"github.com/bossm8/portfoli.go/logging"
+	"github.com/bossm8/portfoli.go/server"
+)
+
+// newFlagSet returns a flag set for the command name, the summary is printed
+// in the help message
+func newFlagSet(name string, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s [flags]\n\n%s\n\nFlags:\n", programName(), name, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// pathFlags contains the flags pointing to the directories used by all commands
+type pathFlags struct {
+	configDir     *string
+	staticDir     *string
+	templatesDir  *string
+	imageCacheDir *string
+}
+
+// defaultConfigDir returns the default location of the yaml configurations
+func defaultConfigDir() string {
+	cfgDir, err := os.UserConfigDir()
+	if err != nil {
+		logging.Fatal(
+			"Could not find the user config dir, please provide the path to the configuration files manually",
+			"error", err,
+		)
+	}
+	return filepath.Join(cfgDir, "portfoli.go", "configs")
+}
+
+// addConfigDirFlag adds the flag for the directory containing the yaml configurations
+func addConfigDirFlag(fs *flag.FlagSet) *string {
+	return fs.String(
+		"config.dir",
+		defaultConfigDir(),
+		"Path to the directory containing the yaml configurations",
+	)
+}
+
+// addPathFlags adds the flags for the directories read by the commands
+func addPathFlags(fs *flag.FlagSet) *pathFlags {
+	return &pathFlags{
+		configDir: addConfigDirFlag(fs),
+		staticDir: fs.String(
+			"static.dir",
+			config.StaticContentPath(),
+			"Path to the directory containing the static content for the website",
+		),
+		templatesDir: fs.String(
+			"templates.dir",
+			config.TemplatesPath(),
+			"Path to the directory containing the html templates",
+		),
+		imageCacheDir: fs.String(
+			"images.cache.dir",
+			"",
+			"Path to the directory where cached images are stored (default: img/cache, relative to static dir only)",
+		),
+	}
+}
+
+// addBasePathFlag adds the flag for the base path the content is served on
+func addBasePathFlag(fs *flag.FlagSet) *string {
+	return fs.String(
+		"srv.base",
+		"/",
+		"The base path to serve content on",
+	)
+}
+
+// addStrictFlag adds the flag enabling the strict yaml decoding
+func addStrictFlag(fs *flag.FlagSet, enabled bool) *bool {
+	return fs.Bool(
+		"strict",
+		enabled,
+		"Reject unknown keys in the yaml configurations (disable for a single file with the comment '# portfoligo:allow-unknown-keys')",
+	)
+}
+
+// logFlags contains the flags configuring the logging
+type logFlags struct {
+	verbose *bool
+	format  *string
+	level   *string
+}
+
+// addLogFlags adds the flags configuring the logging
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		verbose: fs.Bool(
+			"verbose",
+			false,
+			"Print more verbose logging information (sets -log.level to debug and adds filenames)",
+		),
+		format: fs.String(
+			"log.format",
+			logging.FormatText,
+			"Format of the log output, one of text or json",
+		),
+		level: fs.String(
+			"log.level",
+			"info",
+			"Minimum level of log messages, one of debug, info, warn or error",
+		),
+	}
+}
+
+// setup configures the logging, invalid values are reported together with
+// the usage of fs and terminate the program
+func (l *logFlags) setup(fs *flag.FlagSet) {
+	if err := logging.Setup(*l.format, *l.level, *l.verbose); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// serveFlags contains the flags of the server
+type serveFlags struct {
+	addr              *string
+	port              *int
+	tlsCert           *string
+	tlsKey            *string
+	tlsRedirect       *string
+	readTimeout       *time.Duration
+	readHeaderTimeout *time.Duration
+	writeTimeout      *time.Duration
+	idleTimeout       *time.Duration
+	shutdownTimeout   *time.Duration
+	dev               *bool
+	watch             *bool
+	watchInterval     *time.Duration
+	drafts            *bool
+}
+
+// addServeFlags adds the flags of the server (listener, tls, timeouts,
+// reloading and previewing drafts)
+func addServeFlags(fs *flag.FlagSet) *serveFlags {
+	return &serveFlags{
+		addr: fs.String(
+			"srv.address",
+			"127.0.0.1",
+			"Listen address for the protfolio server",
+		),
+		port: fs.Int(
+			"srv.port",
+			8080,
+			"Listen port for the portfolio server",
+		),
+		tlsCert: fs.String(
+			"srv.tls.cert",
+			"",
+			"Path to the PEM encoded TLS certificate, enables HTTPS together with -srv.tls.key (reloaded on change)",
+		),
+		tlsKey: fs.String(
+			"srv.tls.key",
+			"",
+			"Path to the PEM encoded TLS private key",
+		),
+		tlsRedirect: fs.String(
+			"srv.tls.redirect",
+			"",
+			"Listen address of an additional HTTP server which redirects to HTTPS (e.g. 0.0.0.0:8081)",
+		),
+		readTimeout: fs.Duration(
+			"srv.timeout.read",
+			15*time.Second,
+			"Maximum duration for reading an entire request",
+		),
+		readHeaderTimeout: fs.Duration(
+			"srv.timeout.header",
+			5*time.Second,
+			"Maximum duration for reading the headers of a request",
+		),
+		writeTimeout: fs.Duration(
+			"srv.timeout.write",
+			30*time.Second,
+			"Maximum duration for writing a response (must cover sending contact mails)",
+		),
+		idleTimeout: fs.Duration(
+			"srv.timeout.idle",
+			60*time.Second,
+			"Maximum duration to keep idle keep-alive connections open",
+		),
+		shutdownTimeout: fs.Duration(
+			"srv.shutdown.timeout",
+			30*time.Second,
+			"Maximum duration to wait for in-flight requests when shutting down",
+		),
+		dev: fs.Bool(
+			"dev",
+			false,
+			"Development mode, templates and content are reloaded on every request and the browser refreshes on changes",
+		),
+		watch: fs.Bool(
+			"watch",
+			true,
+			"Watch the config, templates and static dir and reload the content when files change",
+		),
+		watchInterval: fs.Duration(
+			"watch.interval",
+			2*time.Second,
+			"Interval in which the directories are checked for changes",
+		),
+		drafts: fs.Bool(
+			"drafts",
+			false,
+			"Render the cards which are not published (drafts, scheduled and expired ones) to preview them locally",
+		),
+	}
+}
+
+// timeouts returns the server timeouts configured by the flags
+func (s *serveFlags) timeouts() server.Timeouts {
+	return server.Timeouts{
+		Read:       *s.readTimeout,
+		ReadHeader: *s.readHeaderTimeout,
+		Write:      *s.writeTimeout,
+		Idle:       *s.idleTimeout,
+		Shutdown:   *s.shutdownTimeout,
+	}
+}
+
+// tlsOptions returns the tls options configured by the flags
+func (s *serveFlags) tlsOptions() server.TLSOptions {
+	return server.TLSOptions{
+		Cert:         *s.tlsCert,
+		Key:          *s.tlsKey,
+		RedirectAddr: *s.tlsRedirect,
+	}
+}
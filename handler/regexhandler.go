@@ -27,60 +27,229 @@
 // OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 
 // Package hanlder contains the implementation of a simple regex handler
+//
+// Patterns are anchored and matched against the complete request path
+// (following the base path), named capture groups (e.g. (?P<slug>[a-z-]+))
+// are passed to the handlers and can be retrieved with r.PathValue
 package handler
 
 import (
+	"context"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 )
 
-type route struct {
+// Route is a single route registered on the RegexHandler
+type Route struct {
+	// name is the pattern as registered (without the base path)
+	name    string
 	pattern *regexp.Regexp
-	handler http.Handler
+	// base is the registered handler (with the base path stripped)
+	base http.Handler
+	// handler is base wrapped with the middlewares of the route
+	handler     http.Handler
+	middlewares []Middleware
+	// methods contains the allowed http methods, nil allows all methods
+	methods map[string]bool
+}
+
+// Use adds middlewares to the route, they are applied after the global
+// ones of the RegexHandler in the order they were added
+func (rt *Route) Use(middlewares ...Middleware) *Route {
+	rt.middlewares = append(rt.middlewares, middlewares...)
+	rt.handler = chain(rt.base, rt.middlewares)
+	return rt
+}
+
+// allows returns true if the route accepts requests with method, HEAD is
+// implicitly allowed for routes accepting GET
+func (rt *Route) allows(method string) bool {
+	if rt.methods == nil {
+		return true
+	}
+	if method == http.MethodHead {
+		return rt.methods[http.MethodHead] || rt.methods[http.MethodGet]
+	}
+	return rt.methods[method]
 }
 
 // RegexHandler implements a http handler with regex pattern matching
 type RegexHandler struct {
-	routes   []*route
+	routes   []*Route
 	basePath string
+	// middlewares are applied to every request, including the ones which
+	// are answered with 404 or 405
+	middlewares []Middleware
+	handler     http.Handler
 }
 
 // Make sure the Handler interface is implemented
 var _ http.Handler = &RegexHandler{}
 
 // SetBasePath sets the base path of the server to path
-// This path will be stripped before the request is passed to any handler
+// This path will be stripped before the request is passed to any handler,
+// it must be set before any routes are registered
 func (h *RegexHandler) SetBasePath(path string) {
 	h.basePath = strings.TrimSuffix(path, "/")
 }
 
+// Use adds middlewares which are applied to every request, the first
+// middleware added is the outermost one
+func (h *RegexHandler) Use(middlewares ...Middleware) {
+	h.middlewares = append(h.middlewares, middlewares...)
+	h.handler = chain(http.HandlerFunc(h.dispatch), h.middlewares)
+}
+
+// Handle registers handler for all requests matching pattern, regardless of
+// their method
 func (h *RegexHandler) Handle(
 	pattern string,
 	handler http.Handler,
-) {
-	h.routes = append(h.routes, &route{
-		pattern: regexp.MustCompile(pattern),
-		handler: http.StripPrefix(h.basePath, handler),
-	})
+) *Route {
+	return h.HandleMethods(pattern, handler)
 }
 
+// HandleFunc registers handler for all requests matching pattern, regardless
+// of their method
 func (h *RegexHandler) HandleFunc(
 	pattern string,
 	handler func(w http.ResponseWriter, r *http.Request),
-) {
-	h.routes = append(h.routes, &route{
-		pattern: regexp.MustCompile(pattern),
-		handler: http.StripPrefix(h.basePath, http.HandlerFunc(handler)),
-	})
+) *Route {
+	return h.HandleMethods(pattern, http.HandlerFunc(handler))
+}
+
+// HandleMethods registers handler for requests matching pattern with one of
+// the given methods, if no methods are given, all methods are allowed
+// Requests matching the pattern with another method are answered with
+// 405 Method Not Allowed, HEAD is allowed whenever GET is and OPTIONS
+// requests are answered automatically, unless explicitly allowed
+func (h *RegexHandler) HandleMethods(
+	pattern string,
+	handler http.Handler,
+	methods ...string,
+) *Route {
+	var allowed map[string]bool
+	if len(methods) > 0 {
+		allowed = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			allowed[strings.ToUpper(method)] = true
+		}
+	}
+	stripped := http.StripPrefix(h.basePath, handler)
+	rt := &Route{
+		name:    pattern,
+		pattern: regexp.MustCompile("^" + regexp.QuoteMeta(h.basePath) + "(?:" + pattern + ")$"),
+		base:    stripped,
+		handler: stripped,
+		methods: allowed,
+	}
+	h.routes = append(h.routes, rt)
+	return rt
+}
+
+// HandleFuncMethods is the same as HandleMethods but takes a handler function
+func (h *RegexHandler) HandleFuncMethods(
+	pattern string,
+	handler func(w http.ResponseWriter, r *http.Request),
+	methods ...string,
+) *Route {
+	return h.HandleMethods(pattern, http.HandlerFunc(handler), methods...)
+}
+
+// allowHeader returns the value of the Allow header for all routes
+// registered with the pattern of the matched route
+func (h *RegexHandler) allowHeader(matched *Route) string {
+	var methods []string
+	for _, rt := range h.routes {
+		if rt.pattern.String() != matched.pattern.String() {
+			continue
+		}
+		for method := range rt.methods {
+			methods = append(methods, method)
+		}
+		if rt.methods[http.MethodGet] {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	methods = append(methods, http.MethodOptions)
+	slices.Sort(methods)
+	return strings.Join(slices.Compact(methods), ", ")
+}
+
+// setPathValues sets the values of all named capture groups of the route
+// pattern in match on r, so they can be retrieved with r.PathValue
+func (rt *Route) setPathValues(r *http.Request, match []string) {
+	for idx, name := range rt.pattern.SubexpNames() {
+		if name != "" {
+			r.SetPathValue(name, match[idx])
+		}
+	}
+}
+
+type matchedRouteKey struct{}
+
+// MatchedPattern returns the pattern (as registered) of the route which
+// matched r, it is empty if no route matched. As the route is only known
+// after dispatching, middlewares must call it after calling the next handler.
+func MatchedPattern(r *http.Request) string {
+	if name, ok := r.Context().Value(matchedRouteKey{}).(*string); ok {
+		return *name
+	}
+	return ""
 }
 
 func (h *RegexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	for _, route := range h.routes {
-		if route.pattern.MatchString(r.URL.Path) {
-			route.handler.ServeHTTP(w, r)
+	r = r.WithContext(context.WithValue(r.Context(), matchedRouteKey{}, new(string)))
+	if h.handler != nil {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	h.dispatch(w, r)
+}
+
+func setMatchedPattern(r *http.Request, rt *Route) {
+	if name, ok := r.Context().Value(matchedRouteKey{}).(*string); ok {
+		*name = rt.name
+	}
+}
+
+// dispatch passes the request to the first route matching it
+func (h *RegexHandler) dispatch(w http.ResponseWriter, r *http.Request) {
+	var matched *Route
+	for _, rt := range h.routes {
+		match := rt.pattern.FindStringSubmatch(r.URL.Path)
+		if match == nil {
+			continue
+		}
+		// only routes registered with the same pattern as the first match
+		// are considered, so a more generic route registered later does not
+		// serve requests with a method not allowed for the matched one
+		if matched != nil && rt.pattern.String() != matched.pattern.String() {
+			continue
+		}
+		if matched == nil {
+			matched = rt
+		}
+		if rt.allows(r.Method) {
+			setMatchedPattern(r, rt)
+			rt.setPathValues(r, match)
+			rt.handler.ServeHTTP(w, r)
 			return
 		}
 	}
-	http.NotFound(w, r)
+
+	if matched == nil {
+		http.NotFound(w, r)
+		return
+	}
+	setMatchedPattern(r, matched)
+
+	w.Header().Set("Allow", h.allowHeader(matched))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
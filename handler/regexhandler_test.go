@@ -31,6 +31,7 @@ package handler_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/bossm8/portfoli.go/handler"
@@ -65,3 +66,145 @@ func TestRegexHandler(t *testing.T) {
 	}
 
 }
+
+func TestRegexHandlerMethods(t *testing.T) {
+
+	okHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	_http := &handler.RegexHandler{}
+	_http.HandleFuncMethods("/mail", okHandler, http.MethodPost)
+	_http.HandleFuncMethods(".*", okHandler, http.MethodGet)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{http.MethodPost, "/mail", http.StatusOK, ""},
+		{http.MethodGet, "/mail", http.StatusMethodNotAllowed, "OPTIONS, POST"},
+		{http.MethodGet, "/experience", http.StatusOK, ""},
+		{http.MethodHead, "/experience", http.StatusOK, ""},
+		{http.MethodDelete, "/experience", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{http.MethodOptions, "/experience", http.StatusNoContent, "GET, HEAD, OPTIONS"},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		_http.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got: %d", tc.method, tc.path, tc.status, rr.Code)
+		}
+		if allow := rr.Header().Get("Allow"); allow != tc.allow {
+			t.Errorf("%s %s: expected Allow '%s', got: '%s'", tc.method, tc.path, tc.allow, allow)
+		}
+	}
+
+}
+
+func TestRegexHandlerRoutes(t *testing.T) {
+
+	pathValueHandler := func(name string) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + r.PathValue(name)))
+		}
+	}
+
+	_http := &handler.RegexHandler{}
+	_http.SetBasePath("/portfolio/")
+	_http.HandleFunc("/static/.*", pathValueHandler("none"))
+	_http.HandleFunc("/projects/(?P<slug>[a-z0-9-]+)", pathValueHandler("slug"))
+	_http.HandleFunc("/(?P<type>experience|education)", pathValueHandler("type"))
+	_http.HandleFunc("/?(?P<page>[^/]*)", pathValueHandler("page"))
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/portfolio", http.StatusOK, "page:"},
+		{"/portfolio/", http.StatusOK, "page:"},
+		{"/portfolio/about", http.StatusOK, "page:about"},
+		{"/portfolio/experience", http.StatusOK, "type:experience"},
+		{"/portfolio/experiences", http.StatusOK, "page:experiences"},
+		{"/portfolio/projects/portfoli-go", http.StatusOK, "slug:portfoli-go"},
+		{"/portfolio/projects/Portfoli.go", http.StatusNotFound, ""},
+		{"/portfolio/static/css/main.css", http.StatusOK, "none:"},
+		{"/portfolio/experience/nested", http.StatusNotFound, ""},
+		{"/other/experience", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		_http.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected %d, got: %d", tc.path, tc.status, rr.Code)
+			continue
+		}
+		if tc.status == http.StatusOK && rr.Body.String() != tc.body {
+			t.Errorf("%s: expected body '%s', got: '%s'", tc.path, tc.body, rr.Body.String())
+		}
+	}
+
+}
+
+func TestRegexHandlerMiddleware(t *testing.T) {
+
+	var order []string
+	middleware := func(name string) handler.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	_http := &handler.RegexHandler{}
+	_http.Use(middleware("global-1"), middleware("global-2"))
+	_http.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}).Use(handler.Recover)
+	_http.HandleFunc("/(?P<page>.*)", func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler:"+r.PathValue("page"))
+	}).Use(middleware("route-1")).Use(middleware("route-2"))
+
+	tests := []struct {
+		path   string
+		status int
+		order  string
+	}{
+		{"/index", http.StatusOK, "global-1,global-2,route-1,route-2,handler:index"},
+		{"/panic", http.StatusInternalServerError, "global-1,global-2"},
+	}
+
+	for _, tc := range tests {
+		order = nil
+		req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		_http.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected %d, got: %d", tc.path, tc.status, rr.Code)
+		}
+		if got := strings.Join(order, ","); got != tc.order {
+			t.Errorf("%s: expected order '%s', got: '%s'", tc.path, tc.order, got)
+		}
+	}
+
+}
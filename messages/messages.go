@@ -33,9 +33,11 @@ package messages
 import (
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/mail"
+
+	"github.com/bossm8/portfoli.go/logging"
 )
 
 // AlertMsg is the object which can be passed down to the status template
@@ -127,11 +129,11 @@ func Compile(emailAddress *mail.Address) {
 // If it cannot find the specified combination, it returns fail/generic
 func Get(endpoint string, kind string) (msg *AlertMsg) {
 	if !compiled {
-		log.Fatal("[ERROR] Please call Compile before requesting a message")
+		logging.Fatal("Please call Compile before requesting a message")
 	}
 	var ok bool
 	if msg, ok = messages[MessageEndpoint(endpoint)][MessageType(kind)]; !ok {
-		log.Printf("[WARNING] Invalid message requested: %s/%s\n", endpoint, kind)
+		slog.Warn("Invalid message requested", "endpoint", endpoint, "kind", kind)
 		return messages[EndpointFail][MsgGeneric]
 	}
 	return
@@ -0,0 +1,193 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package metrics contains the prometheus metrics exposed by the server
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bossm8/portfoli.go/handler"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "portfoligo"
+
+// Outcomes of contact form submissions
+const (
+	ContactSuccess        = "success"
+	ContactInvalidForm    = "invalid_form"
+	ContactInvalidAddress = "invalid_address"
+	ContactSMTPFailure    = "smtp_failure"
+)
+
+// Results of image cache lookups and downloads
+const (
+	ImageCacheHit     = "hit"
+	ImageCacheMiss    = "miss"
+	ImageDownloadOK   = "success"
+	ImageDownloadFail = "failure"
+)
+
+// unmatchedRoute is the route label of requests not matching any route
+const unmatchedRoute = "unmatched"
+
+// otherMethod is the method label of requests with a non standard method
+const otherMethod = "other"
+
+// knownMethods are the request methods used as label value as they are,
+// all others are recorded as otherMethod to keep the number of series bounded
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled http requests by route, method and status code.",
+		},
+		[]string{"route", "method", "code"},
+	)
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of handled http requests by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method"},
+	)
+	contactSubmissions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "contact_submissions_total",
+			Help:      "Number of contact form submissions by outcome.",
+		},
+		[]string{"outcome"},
+	)
+	templateRenderFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "template_render_failures_total",
+			Help:      "Number of failed template renderings by template name.",
+		},
+		[]string{"template"},
+	)
+	imageCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "image_cache_lookups_total",
+			Help:      "Number of remote image lookups in the image cache by result (hit, miss).",
+		},
+		[]string{"result"},
+	)
+	imageDownloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "image_downloads_total",
+			Help:      "Number of remote images downloaded into the image cache by outcome.",
+		},
+		[]string{"outcome"},
+	)
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		contactSubmissions,
+		templateRenderFailures,
+		imageCacheLookups,
+		imageDownloads,
+	)
+}
+
+// Handler returns the handler exposing the metrics in the prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ContactSubmission counts a contact form submission with the given outcome
+func ContactSubmission(outcome string) {
+	contactSubmissions.WithLabelValues(outcome).Inc()
+}
+
+// TemplateRenderFailure counts a failed rendering of the template with name
+func TemplateRenderFailure(name string) {
+	templateRenderFailures.WithLabelValues(name).Inc()
+}
+
+// ImageCacheLookup counts a lookup in the image cache with the given result
+func ImageCacheLookup(result string) {
+	imageCacheLookups.WithLabelValues(result).Inc()
+}
+
+// ImageDownload counts a download of an image with the given outcome
+func ImageDownload(outcome string) {
+	imageDownloads.WithLabelValues(outcome).Inc()
+}
+
+// Middleware records the count and latency of requests per route of the
+// handler.RegexHandler it is used on
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := handler.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		route := handler.MatchedPattern(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = otherMethod
+		}
+		requests.WithLabelValues(route, method, strconv.Itoa(rec.Status())).Inc()
+		requestDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	})
+}
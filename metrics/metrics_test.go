@@ -0,0 +1,109 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bossm8/portfoli.go/handler"
+	"github.com/bossm8/portfoli.go/metrics"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetricsScrape(t *testing.T) {
+
+	_http := &handler.RegexHandler{}
+	_http.Use(metrics.Middleware)
+	_http.HandleFuncMethods("/(?P<type>experience|projects)", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, http.MethodGet)
+
+	for _, path := range []string{"/experience", "/projects", "/unknown/path"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_http.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		req, err := http.NewRequest(method, "/unknown/path", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_http.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	metrics.ContactSubmission(metrics.ContactSMTPFailure)
+	metrics.TemplateRenderFailure("content")
+	metrics.ImageCacheLookup(metrics.ImageCacheHit)
+	metrics.ImageDownload(metrics.ImageDownloadOK)
+
+	body := scrape(t)
+
+	expected := []string{
+		`portfoligo_http_requests_total{code="200",method="GET",route="/(?P<type>experience|projects)"} 2`,
+		`portfoligo_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`portfoligo_http_requests_total{code="404",method="other",route="unmatched"} 2`,
+		`portfoligo_http_request_duration_seconds_count{method="GET",route="/(?P<type>experience|projects)"} 2`,
+		`portfoligo_contact_submissions_total{outcome="smtp_failure"} 1`,
+		`portfoligo_template_render_failures_total{template="content"} 1`,
+		`portfoligo_image_cache_lookups_total{result="hit"} 1`,
+		`portfoligo_image_downloads_total{outcome="success"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected scrape to contain '%s'", line)
+		}
+	}
+
+}
@@ -31,11 +31,15 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
 
+	"github.com/bossm8/portfoli.go/logging"
 	apputils "github.com/bossm8/portfoli.go/utils"
 
 	"github.com/bossm8/portfoli.go/models/content"
@@ -50,9 +54,9 @@ const (
 // SocialMedia represents a generic social media type
 type SocialMedia struct {
 	// Type of media, should be one of the 'social' type icons of https://icons.getbootstrap.com/#icons
-	Type string `yaml:"type"`
+	Type string `yaml:"type" json:"type,omitempty" validate:"required"`
 	// Link to the social media profile
-	Link string `yaml:"link"`
+	Link string `yaml:"link" json:"link,omitempty" validate:"required,url"`
 }
 
 var (
@@ -66,34 +70,34 @@ var ErrInvalidSMTPConfig = errors.New("invalid SMTP configuration")
 // be highlighted in the portfolio - (optional) means if null, no page will be rendered
 type ProfileConfig struct {
 	// BrandName is the name displayed in the navigation bar
-	BrandName string `yaml:"brandname"`
+	BrandName string `yaml:"brandname" json:"brandname,omitempty"`
 	// BrandImage is the image displayed in the navigation bar
-	BrandImage *template.HTML `yaml:"brandimage"`
+	BrandImage *template.HTML `yaml:"brandimage" json:"brandimage,omitempty"`
 	// BannerImage is the image displayed on the index page
-	BannerImage string `yaml:"bannerimage"`
+	BannerImage string `yaml:"bannerimage" json:"bannerimage,omitempty" validate:"image"`
 	// Avatar displayed as profile image
-	Avatar string `yaml:"avatar"`
+	Avatar string `yaml:"avatar" json:"avatar,omitempty" validate:"image"`
 	// FirstName displayed for the profile
-	FirstName string `yaml:"firstname"`
+	FirstName string `yaml:"firstname" json:"firstname,omitempty"`
 	// LastName displayed for the profile
-	LastName string `yaml:"lastname"`
+	LastName string `yaml:"lastname" json:"lastname,omitempty"`
 	// Contact email address for the profile
-	Email *EmailAddress `yaml:"email"`
+	Email *EmailAddress `yaml:"email" json:"email,omitempty"`
 	// Heading shown on the index page
-	Heading *template.HTML `yaml:"heading"`
+	Heading *template.HTML `yaml:"heading" json:"heading,omitempty"`
 	// SubHeading shown on the index page
-	SubHeading *template.HTML `yaml:"subheading"`
+	SubHeading *template.HTML `yaml:"subheading" json:"subheading,omitempty"`
 	// Slogan shown on the index page
-	Slogan string `yaml:"slogan"`
+	Slogan string `yaml:"slogan" json:"slogan,omitempty"`
 	// Heading shown on the contact page
-	ContactHeading string `yaml:"contactheading"`
+	ContactHeading string `yaml:"contactheading" json:"contactheading,omitempty"`
 	// All links to social media, displayed in the footer bar and on the index page
-	SocialMedia []*SocialMedia `yaml:"social"`
+	SocialMedia []*SocialMedia `yaml:"social" json:"social,omitempty"`
 	// ContentTypes enabled for the page (each element is optional)
 	// - the element itself not and the list must be valid content types
-	ContentTypes []string `yaml:"content"`
+	ContentTypes []string `yaml:"content" json:"content,omitempty"`
 	// Animations defines if animations should be added to the page or not
-	Animations bool `yaml:"animations"`
+	Animations bool `yaml:"animations" json:"animations,omitempty"`
 }
 
 // ImagesConfig contains configuration for image caching behavior
@@ -104,6 +108,20 @@ type ImagesConfig struct {
 	Force bool `yaml:"force"`
 }
 
+// MarkdownConfig contains the configuration for html fields written in markdown
+type MarkdownConfig struct {
+	// Policy is the bluemonday policy sanitizing the rendered html: ugc (default),
+	// strict (text only) or none
+	Policy string `yaml:"policy"`
+	// Style is the chroma style used to highlight fenced code blocks (default: github)
+	Style string `yaml:"style"`
+}
+
+// Validate checks that the policy and style exist
+func (m *MarkdownConfig) Validate() error {
+	return utils.CheckMarkdownOptions(m.Policy, m.Style)
+}
+
 // SEOConfig contains configuration for page title/metadata and social
 // share previews
 type SEOConfig struct {
@@ -114,11 +132,11 @@ type SEOConfig struct {
 	Description string `yaml:"description"`
 	// Image used for social share previews (og:image/twitter:image),
 	// falls back to profile.avatar when unset
-	Image string `yaml:"image"`
+	Image string `yaml:"image" validate:"image"`
 	// SiteURL is the absolute base URL of the deployed site (e.g.
 	// https://example.com), optional - if set, it is used to build an
 	// absolute image URL for maximum share-card compatibility
-	SiteURL string `yaml:"siteurl"`
+	SiteURL string `yaml:"siteurl" validate:"url"`
 }
 
 // RenderHTML renders all HTML fields of the profile by passing them through the
@@ -129,7 +147,7 @@ func (p *ProfileConfig) RenderHTML() error {
 		if res, ok := val.Field(i).Interface().(*template.HTML); ok {
 			newHTML, err := apputils.ProcessHTMLContent(res)
 			if err != nil {
-				log.Printf("failed to process HTML template for %s", val.Type().Field(i).Name)
+				slog.Error("Failed to process HTML template", "field", val.Type().Field(i).Name)
 				return err
 			}
 			*res = *newHTML
@@ -206,7 +224,7 @@ func (p *ProfileConfig) PersonJSONLD(seo *SEOConfig, image string) template.JS {
 	}
 	b, err := json.Marshal(schema)
 	if err != nil {
-		log.Printf("[WARNING] Failed to marshal person JSON-LD: %s\n", err)
+		slog.Warn("Failed to marshal person JSON-LD", "error", err)
 		return ""
 	}
 	return template.JS(b)
@@ -223,10 +241,49 @@ type Config struct {
 	SMTP *SMTPConfig `yaml:"smtp"`
 	// Images configuration for caching remote images
 	Images *ImagesConfig `yaml:"images"`
+	// Server configuration of the dynamic server (ignored by the static build)
+	Server *ServerConfig `yaml:"server"`
+	// Markdown configuration for html fields written in markdown
+	Markdown *MarkdownConfig `yaml:"markdown"`
+	// CustomContent declares additional card content types
+	CustomContent []*content.CustomType `yaml:"customcontent"`
+	// Sort configures the order of the cards by content type
+	Sort map[string]*content.SortOrder `yaml:"sort"`
+	// Dates configures the language and wording of the dates of the cards
+	Dates *content.DateConfig `yaml:"dates"`
 	// RenderContact signals if the contact form should be rendered or not
 	RenderContact bool
 }
 
+// Validate checks that all enabled (and sorted) content types exist or are
+// declared as custom content
+func (c *Config) Validate() error {
+	declared := make(map[string]bool, len(c.CustomContent))
+	for _, custom := range c.CustomContent {
+		declared[custom.Name] = true
+	}
+	for _, contentType := range slices.Sorted(maps.Keys(c.Sort)) {
+		if _, ok := content.Lookup(contentType); !ok && !declared[contentType] {
+			return &utils.KeyError{
+				Key: "sort." + contentType,
+				Err: fmt.Errorf("invalid content type '%s', allowed are %v", contentType, content.TypeNames()),
+			}
+		}
+	}
+	if c.Profile == nil {
+		return nil
+	}
+	for _, contentType := range c.Profile.ContentTypes {
+		if _, ok := content.Lookup(contentType); !ok && !declared[contentType] {
+			return &utils.KeyError{
+				Key: "profile.content",
+				Err: fmt.Errorf("invalid content type '%s', allowed are %v", contentType, content.TypeNames()),
+			}
+		}
+	}
+	return nil
+}
+
 // Load loads and returns the configuration from <config.dir>/config.yaml
 func Load() (*Config, error) {
 	// Default values which well be used on first load when nothing is configured
@@ -243,25 +300,78 @@ func Load() (*Config, error) {
 	if err := utils.LoadFromYAMLFile(ConfigFile, cfg); nil != err {
 		return nil, err
 	}
+	overridden, err := ApplyEnvOverrides(cfg)
+	if err != nil {
+		slog.Error("Failed to apply the environment overrides", "error", err)
+		return nil, err
+	}
+	if len(overridden) > 0 {
+		// never log the values, they may contain secrets
+		slog.Info("Configuration keys overridden from the environment", "keys", overridden)
+	}
 	if cfg.Images == nil {
 		cfg.Images = &ImagesConfig{}
 	}
+	if cfg.Server == nil {
+		cfg.Server = &ServerConfig{}
+	}
+	if cfg.Server.TLS == nil {
+		cfg.Server.TLS = &TLSConfig{}
+	}
+	if cfg.Server.Metrics == nil {
+		cfg.Server.Metrics = &MetricsConfig{}
+	}
+	if cfg.Server.Readiness == nil {
+		cfg.Server.Readiness = &ReadinessConfig{}
+	}
 
+	if cfg.Markdown == nil {
+		cfg.Markdown = &MarkdownConfig{}
+	}
+	// the content files loaded afterwards are rendered with these options
+	if err := utils.SetMarkdownOptions(cfg.Markdown.Policy, cfg.Markdown.Style); err != nil {
+		slog.Error("Invalid markdown configuration", "error", err)
+		return nil, err
+	}
+
+	if err := content.SetCustomTypes(cfg.CustomContent); err != nil {
+		slog.Error("Invalid custom content", "error", err)
+		return nil, err
+	}
 	for _, contentType := range cfg.Profile.ContentTypes {
 		if !content.IsValidContentType(contentType) {
 			return nil, errors.New("invalid content kind " + contentType)
 		}
 	}
+	content.SetEnabledTypes(cfg.Profile.ContentTypes)
+	for contentType := range cfg.Sort {
+		if !content.IsValidContentType(contentType) {
+			return nil, errors.New("invalid content kind " + contentType + " in sort")
+		}
+	}
+	if err := content.SetSortOrders(cfg.Sort); err != nil {
+		slog.Error("Invalid sort order", "error", err)
+		return nil, err
+	}
+	if err := content.SetDateConfig(cfg.Dates); err != nil {
+		slog.Error("Invalid date configuration", "error", err)
+		return nil, err
+	}
 
 	// All configuration of smtp is required for the mailing service to be working
 	// as yaml.v3 does not yet have a required tag, the check is made manually
 	cfg.RenderContact = true
+	if cfg.SMTP == nil {
+		slog.Info("No SMTP config found, the contact form is disabled")
+		cfg.RenderContact = false
+		return cfg, ErrInvalidSMTPConfig
+	}
 	val := reflect.ValueOf(*cfg.SMTP)
 	for i := 0; i < val.NumField(); i++ {
 		if v := val.Field(i); v.IsZero() {
-			log.Printf(
-				"[ERROR] SMTP config lacking a correct value for '%s'\n",
-				strings.ToLower(val.Type().Field(i).Name),
+			slog.Error(
+				"SMTP config lacking a correct value",
+				"key", strings.ToLower(val.Type().Field(i).Name),
 			)
 			cfg.RenderContact = false
 			return cfg, ErrInvalidSMTPConfig
@@ -273,7 +383,7 @@ func Load() (*Config, error) {
 // Get returns the loaded config (Load must have been called at least once, else it will fail)
 func Get() *Config {
 	if cfg == nil {
-		log.Fatalln("[ERROR] Cannot return config, please call LoadConfig first")
+		logging.Fatal("Cannot return config, please call LoadConfig first")
 	}
 	return cfg
 }
@@ -28,8 +28,102 @@
 
 package config
 
-import "testing"
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeSMTP starts a listener which greets every connection with greeting and
+// returns its address
+func fakeSMTP(t *testing.T, greeting string) (string, int) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.Write([]byte(greeting + "\r\n"))
+				if line, err := bufio.NewReader(conn).ReadString('\n'); err == nil && line == "QUIT\r\n" {
+					conn.Write([]byte("221 Bye\r\n"))
+				}
+			}(conn)
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
 
 func TestInvalidContentTypeSettings(t *testing.T) {
 
 }
+
+func TestSMTPPing(t *testing.T) {
+
+	host, port := fakeSMTP(t, "220 fake.smtp ESMTP ready")
+	smtp := &SMTPConfig{Host: host, Port: port}
+	if err := smtp.Ping(time.Second); err != nil {
+		t.Fatalf("Expected ping to succeed, got: %s", err)
+	}
+
+	host, port = fakeSMTP(t, "554 go away")
+	smtp = &SMTPConfig{Host: host, Port: port}
+	if err := smtp.Ping(time.Second); err == nil {
+		t.Fatal("Expected ping to fail on a rejecting greeting")
+	}
+
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORTFOLIGO_SMTP_PASS_FILE", secret)
+	t.Setenv("PORTFOLIGO_SMTP_PORT", "2525")
+	t.Setenv("PORTFOLIGO_PROFILE_CONTENT", "[bio, projects]")
+	t.Setenv("PORTFOLIGO_PROFILE_HEADING", "<b>Hi: there</b>")
+
+	cfg := &Config{Profile: &ProfileConfig{FirstName: "Gopher"}}
+	keys, err := ApplyEnvOverrides(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"profile.heading", "profile.content", "smtp.pass", "smtp.port"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected overridden keys %v, got %v", expected, keys)
+	}
+	if cfg.SMTP == nil || cfg.SMTP.Pass != "s3cret" || cfg.SMTP.Port != 2525 {
+		t.Errorf("smtp not overridden correctly: %+v", cfg.SMTP)
+	}
+	if !slices.Equal(cfg.Profile.ContentTypes, []string{"bio", "projects"}) {
+		t.Errorf("content not overridden correctly: %v", cfg.Profile.ContentTypes)
+	}
+	if cfg.Profile.Heading == nil || *cfg.Profile.Heading != "<b>Hi: there</b>" {
+		t.Errorf("heading not overridden correctly: %v", cfg.Profile.Heading)
+	}
+	if cfg.Profile.FirstName != "Gopher" || cfg.Server != nil {
+		t.Error("keys without override must be left untouched")
+	}
+
+	t.Setenv("PORTFOLIGO_SMTP_PASS", "other")
+	if _, err := ApplyEnvOverrides(&Config{}); err == nil {
+		t.Error("expected an error if a key and its file are set")
+	}
+}
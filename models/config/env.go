@@ -0,0 +1,153 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix is the prefix of environment variables overriding config keys
+	EnvPrefix = "PORTFOLIGO_"
+	// envFileSuffix marks environment variables containing the path to a
+	// file with the value, e.g. a docker or kubernetes secret
+	envFileSuffix = "_FILE"
+)
+
+// EnvName returns the name of the environment variable overriding the
+// config key (e.g. smtp.pass -> PORTFOLIGO_SMTP_PASS)
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// lookupEnv returns the override for key from the environment, either from
+// the variable itself or from the file the *_FILE variable points to
+func lookupEnv(key string) (string, bool, error) {
+	name := EnvName(key)
+	value, ok := os.LookupEnv(name)
+	file, fromFile := os.LookupEnv(name + envFileSuffix)
+	switch {
+	case ok && fromFile:
+		return "", false, fmt.Errorf("both %s and %s are set", name, name+envFileSuffix)
+	case fromFile:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, fmt.Errorf("reading %s: %w", name+envFileSuffix, err)
+		}
+		// files usually end with a newline which is not part of the secret
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return value, ok, nil
+}
+
+// ApplyEnvOverrides sets the values of the config keys found in the
+// environment (see EnvName) on cfg and returns the keys which were
+// overridden. Strings are taken as they are, all other values are
+// decoded as yaml (e.g. PORTFOLIGO_PROFILE_CONTENT='[bio, projects]').
+func ApplyEnvOverrides(cfg *Config) ([]string, error) {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), "")
+}
+
+// applyEnv walks the struct val and applies the overrides of its keys,
+// prefix is prepended to the keys of the fields (e.g. "smtp.")
+func applyEnv(val reflect.Value, prefix string) ([]string, error) {
+	var overridden []string
+	for i := 0; i < val.NumField(); i++ {
+		f := val.Type().Field(i)
+		tag := f.Tag.Get("yaml")
+		if !f.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		// the keys of inlined structs are on the same level
+		fieldPrefix := prefix + name + "."
+		if strings.Contains(opts, "inline") {
+			fieldPrefix = prefix
+		}
+		keys, err := applyEnvField(val.Field(i), prefix+name, fieldPrefix)
+		if err != nil {
+			return nil, err
+		}
+		overridden = append(overridden, keys...)
+	}
+	return overridden, nil
+}
+
+// applyEnvField applies the override of key to field, nested structs are
+// walked with prefix and only allocated if one of their keys is overridden
+func applyEnvField(field reflect.Value, key string, prefix string) ([]string, error) {
+	t := field.Type()
+	switch {
+	case t.Kind() == reflect.Struct && !isDecodedAsValue(t):
+		return applyEnv(field, prefix)
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && !isDecodedAsValue(t.Elem()):
+		target := field
+		if field.IsNil() {
+			target = reflect.New(t.Elem())
+		}
+		keys, err := applyEnv(target.Elem(), prefix)
+		if err == nil && len(keys) > 0 && field.IsNil() {
+			field.Set(target)
+		}
+		return keys, err
+	}
+
+	value, ok, err := lookupEnv(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		field.SetString(value)
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.String:
+		// e.g. *template.HTML
+		str := reflect.New(t.Elem())
+		str.Elem().SetString(value)
+		field.Set(str)
+	default:
+		target := reflect.New(t)
+		if err := yaml.Unmarshal([]byte(value), target.Interface()); err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %w", EnvName(key), err)
+		}
+		field.Set(target.Elem())
+	}
+	return []string{key}, nil
+}
+
+// isDecodedAsValue returns true for structs decoded from a single yaml
+// value (e.g. EmailAddress)
+func isDecodedAsValue(t reflect.Type) bool {
+	unmarshaler := reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	return t.Implements(unmarshaler) || reflect.PointerTo(t).Implements(unmarshaler)
+}
@@ -29,9 +29,15 @@
 package config
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/mail"
+	"net/textproto"
+	"strconv"
+	"time"
 
 	appconfig "github.com/bossm8/portfoli.go/config"
 	apputils "github.com/bossm8/portfoli.go/utils"
@@ -43,6 +49,8 @@ import (
 const (
 	// Subject which will be used in the contact emails
 	subject = "[Portfolio] New message from %s"
+	// smtpsPort is the port on which gomail uses implicit TLS (SMTPS)
+	smtpsPort = 465
 )
 
 // SMTPConfig contains the configuration of the mailing service
@@ -50,13 +58,13 @@ type SMTPConfig struct {
 	// User which is used to login to the smpt service
 	// Should be an email, because emails will be sent with this address used
 	// as the From header (will be checked on loading)
-	User EmailAddress `yaml:"user"`
+	User EmailAddress `yaml:"user" validate:"required"`
 	// Password which is used to login to the smpt service
-	Pass string `yaml:"pass"`
+	Pass string `yaml:"pass" validate:"required"`
 	// The smtp host which will send the emails
-	Host string `yaml:"host"`
+	Host string `yaml:"host" validate:"required"`
 	// The port on which the smtp host listens on
-	Port int `yaml:"port"`
+	Port int `yaml:"port" validate:"required"`
 }
 
 // SendMail sends the email message to receiver via the configured smtp service
@@ -76,19 +84,53 @@ func (smtp *SMTPConfig) SendMail(
 	})
 	mail.SetBody("text/plain", message)
 	if html, err := renderMailHTML(senderName, replyTo.Address, message); nil != err {
-		log.Printf("[WARNING] Failed to render HTML mail body, sending plain text only: %s\n", err)
+		slog.Warn("Failed to render HTML mail body, sending plain text only", "error", err)
 	} else {
 		mail.AddAlternative("text/html", html)
 	}
 
 	dialer := gomail.NewDialer(smtp.Host, smtp.Port, smtp.User.Address.Address, smtp.Pass)
 	if err := dialer.DialAndSend(mail); err != nil {
-		log.Printf("[ERROR] Could not send email: %s\n", err)
+		slog.Error("Could not send email", "error", err)
 		return err
 	}
 	return nil
 }
 
+// Ping checks if the smtp host accepts connections by waiting for its
+// greeting, it does not authenticate. Like SendMail, the connection to the
+// SMTPS port is wrapped in TLS right away
+func (smtp *SMTPConfig) Ping(timeout time.Duration) error {
+	addr := net.JoinHostPort(smtp.Host, strconv.Itoa(smtp.Port))
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if smtp.Port == smtpsPort {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: smtp.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	text := textproto.NewConn(conn)
+	if _, _, err := text.ReadResponse(220); err != nil {
+		return fmt.Errorf("unexpected greeting from %s: %w", addr, err)
+	}
+	// be polite, the result does not matter anymore
+	if id, err := text.Cmd("QUIT"); err == nil {
+		text.StartResponse(id)
+		text.ReadResponse(221)
+		text.EndResponse(id)
+	}
+	return nil
+}
+
 // mailData is passed to the mail html template
 type mailData struct {
 	Name    string
@@ -117,13 +159,26 @@ type EmailAddress struct {
 // UmarshalYAML unmarshals the string address from yaml into an EmailAddress
 func (m *EmailAddress) UnmarshalYAML(value *yaml.Node) error {
 	if addr, err := mail.ParseAddress(value.Value); nil != err {
-		log.Fatalf("[ERROR]: Invalid profile email address %s", value.Value)
-		return err
+		// an error instead of exiting, so a reload with a typo does not stop the server
+		return fmt.Errorf("line %d: invalid email address '%s': %w", value.Line, value.Value, err)
 	} else {
 		m.Address = addr
 	}
 	return nil
 }
 
-// Make sure the Unmarshaler interface is implemented
+// MarshalJSON marshals the address as plain string, the same as it is
+// written in yaml
+func (m EmailAddress) MarshalJSON() ([]byte, error) {
+	if m.Address == nil {
+		return []byte("null"), nil
+	}
+	if m.Name == "" {
+		return json.Marshal(m.Address.Address)
+	}
+	return json.Marshal(m.Address.String())
+}
+
+// Make sure the Unmarshaler and Marshaler interfaces are implemented
 var _ yaml.Unmarshaler = &EmailAddress{}
+var _ json.Marshaler = EmailAddress{}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+// ServerConfig contains settings for the dynamic server, the values can be
+// overridden by the corresponding command line flags
+type ServerConfig struct {
+	// TLS configuration to serve the portfolio via HTTPS
+	TLS *TLSConfig `yaml:"tls"`
+	// Metrics configuration of the prometheus endpoint
+	Metrics *MetricsConfig `yaml:"metrics"`
+	// Readiness configuration of the /readyz endpoint
+	Readiness *ReadinessConfig `yaml:"readiness"`
+}
+
+// ReadinessConfig controls the optional checks of the readiness endpoint
+type ReadinessConfig struct {
+	// SMTP additionally checks if the smtp server accepts connections
+	SMTP bool `yaml:"smtp"`
+}
+
+// MetricsConfig controls if and where prometheus metrics are exposed
+type MetricsConfig struct {
+	// Enabled exposes the metrics on /metrics
+	Enabled bool `yaml:"enabled"`
+	// Address is an optional separate listen address for the metrics
+	// endpoint (e.g. 0.0.0.0:9090), if empty, the metrics are served on
+	// the portfolio server (respecting the base path)
+	Address string `yaml:"address"`
+}
+
+// TLSConfig contains the paths to the certificate and key used to serve HTTPS
+type TLSConfig struct {
+	// Cert is the path to the PEM encoded certificate (chain)
+	Cert string `yaml:"cert"`
+	// Key is the path to the PEM encoded private key
+	Key string `yaml:"key"`
+	// Redirect is the address of an optional plain HTTP listener which
+	// redirects all requests to HTTPS (e.g. 0.0.0.0:8081)
+	Redirect string `yaml:"redirect"`
+}
+
+// Enabled returns true if both certificate and key are configured
+func (t *TLSConfig) Enabled() bool {
+	return t != nil && t.Cert != "" && t.Key != ""
+}
@@ -30,37 +30,34 @@ package content
 
 import (
 	"html/template"
-	"log"
+	"log/slog"
 	"path/filepath"
 
 	"github.com/bossm8/portfoli.go/config"
 	apputils "github.com/bossm8/portfoli.go/utils"
 )
 
+func init() {
+	MustRegister(&Type{
+		Name:  "bio",
+		Title: "Bio'n'Skills",
+		New:   func() ContentConfig { return &AboutMeConfig{} },
+	})
+}
+
 type AboutMeConfig struct {
-	AboutMe template.HTML `yaml:"me"`
+	TypeInfo `yaml:"-" json:"-"`
+	AboutMe  template.HTML `yaml:"me" json:"me,omitempty"`
 }
 
 // Make sure the interface is implemented
 var _ ContentConfig = &AboutMeConfig{}
 
-func (a *AboutMeConfig) ConfigName() string {
-	return a.ContentType() + ".yml"
-}
-
-func (a *AboutMeConfig) ContentType() string {
-	return ContentTypes[typeAbout]
-}
-
-func (a *AboutMeConfig) Title() string {
-	return "Bio'n'Skills"
-}
-
 func (a *AboutMeConfig) Render() (*template.HTML, error) {
 	baseTpl := filepath.Join(config.ContentTemplatesPath(), a.ContentType()+".html")
 	result, err := apputils.RenderTemplate(a.ContentType(), a.AboutMe, baseTpl)
 	if err != nil {
-		log.Printf("[ERROR] Failed to render %s\n", baseTpl)
+		slog.Error("Failed to render template", "template", baseTpl)
 		return nil, err
 	}
 	html := template.HTML(result)
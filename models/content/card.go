@@ -29,23 +29,35 @@
 package content
 
 import (
+	"errors"
+	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"time"
 
 	"github.com/bossm8/portfoli.go/config"
 	apputils "github.com/bossm8/portfoli.go/utils"
+
+	"github.com/bossm8/portfoli.go/models/utils"
 )
 
 const (
 	cardsTpl = "cards.html"
+	// contentBaseTplFile is the template every card template is rendered with
+	contentBaseTplFile = "base.html"
+	// cardTplName is the name of the template rendering a single card
+	cardTplName = "content"
 )
 
-// Card defines an element which will be rendered as a card
+// Card defines an element which will be rendered as a card (with the card
+// template of its content type)
 type Card interface {
-	// CardTemplateName returns the name of the corresponding card html template
-	CardTemplateName() string
+	// ImageRef returns a pointer to the image of the card for cache
+	// updates, nil if the card has no image
+	ImageRef() *string
+	// Base returns the attributes shared by all cards
+	Base() *CardBase
 }
 
 // CardContentConfig defines a specific type of content config,
@@ -60,13 +72,36 @@ type CardContentConfig interface {
 // CardBase contains shared attributes for all card content types
 type CardBase struct {
 	// Image to render in the card
-	Image string `yaml:"image"`
+	Image string `yaml:"image" json:"image,omitempty" validate:"image"`
 	// Name to display in the heading
-	Name string `yaml:"name"`
+	Name string `yaml:"name" json:"name,omitempty" validate:"required"`
 	// Link to external content
-	Link string `yaml:"link"`
+	Link string `yaml:"link" json:"link,omitempty" validate:"url"`
 	// Description displayed in the card body
-	Description template.HTML `yaml:"description"`
+	Description template.HTML `yaml:"description" json:"description,omitempty"`
+	// Slug identifies the card in the path of its detail page, defaults to
+	// the name in lower case with dashes (e.g. 'portfoli-go')
+	Slug string `yaml:"slug" json:"slug,omitempty"`
+	// Body is the long-form content of the detail page, cards without a body
+	// have no detail page and link to Link instead
+	Body template.HTML `yaml:"body" json:"body,omitempty"`
+	// Tags of the card, each links to the cards of the content type with the
+	// same tag (see TagPath)
+	Tags []string `yaml:"tags" json:"tags,omitempty"`
+	// Pinned cards are listed before all others, regardless of the sort order
+	Pinned bool `yaml:"pinned" json:"pinned,omitempty"`
+	// Draft cards are only rendered when drafts are previewed (see SetPreviewDrafts)
+	Draft bool `yaml:"draft" json:"draft,omitempty"`
+	// Hidden cards are not listed, but their detail page is still rendered
+	Hidden bool `yaml:"hidden" json:"hidden,omitempty"`
+	// PublishAfter is the point in time (or date) from which on the card is rendered
+	PublishAfter time.Time `yaml:"publishafter" json:"publishafter,omitempty"`
+	// ExpireAfter is the point in time (or date) after which the card is no longer rendered
+	ExpireAfter time.Time `yaml:"expireafter" json:"expireafter,omitempty"`
+	// detailPath is the path of the detail page (see prepareCards)
+	detailPath string
+	// contentType is the name of the content type of the card (see prepareCards)
+	contentType string
 }
 
 // ImageRef returns a pointer to the image field for cache updates.
@@ -74,38 +109,78 @@ func (c *CardBase) ImageRef() *string {
 	return &c.Image
 }
 
+// Base returns the card itself, it makes the shared attributes accessible
+// through the Card interface
+func (c *CardBase) Base() *CardBase {
+	return c
+}
+
+// GetSlug returns the explicit slug of the card or the one derived from its name
+func (c *CardBase) GetSlug() string {
+	if c.Slug != "" {
+		return c.Slug
+	}
+	return slugify(c.Name)
+}
+
+// TagPath returns the path of the page listing the cards of the same content
+// type tagged with tag (e.g. /projects/tags/go)
+func (c *CardBase) TagPath(tag string) string {
+	return "/" + c.contentType + "/tags/" + slugify(tag)
+}
+
+// DetailPath returns the path of the detail page of the card (e.g.
+// /projects/portfoli-go), empty if the card has no body
+func (c *CardBase) DetailPath() string {
+	return c.detailPath
+}
+
 // CardDateRange specifies a range of two dates
 type CardDateRange struct {
-	// From a date
-	From time.Time `yaml:"from"`
-	// To, may be string or date format
-	To interface{} `yaml:"to"`
-	// The format in which the date is present and should be rendered
-	Format string `yaml:"dateformat"`
+	// From a date, which may be written as 2021-03-14, 2021-03 or 2021
+	From Date `yaml:"from" json:"from,omitempty"`
+	// To, may be a date (with the same precisions as From) or a text like
+	// 'present', ranges without it have not ended yet
+	To interface{} `yaml:"to" json:"to,omitempty"`
+	// The format in which the date is present and should be rendered, the
+	// default depends on the precision of the date (see DateConfig)
+	Format string `yaml:"dateformat" json:"dateformat,omitempty"`
 }
 
-func (d *CardDateRange) setFormat() {
-	if d.Format == "" {
-		d.Format = "2006-01-02"
+// errMissingFrom is returned for date ranges without start
+var errMissingFrom = errors.New("missing the start of the date range")
+
+// Validate checks that the range has a start and does not end before it
+func (d *CardDateRange) Validate() error {
+	if d.From.IsZero() {
+		return &utils.KeyError{Key: "from", Err: errMissingFrom}
+	}
+	if to, ok := d.end(); ok && d.From.After(to.periodEnd()) {
+		return &utils.KeyError{
+			Key: "to",
+			Err: fmt.Errorf("ends (%s) before it starts (%s)", to.Format(time.DateOnly), d.From.Format(time.DateOnly)),
+		}
 	}
+	return nil
 }
 
 // GetFromDateAsStr returns the date formatted as string
 func (d *CardDateRange) GetFromDateAsStr() string {
-	d.setFormat()
-	return d.From.Format(d.Format)
+	style := getDateStyle()
+	return style.format(d.From.Time, style.layout(d.Format, d.From))
 }
 
 // GetToDateAsStr checks if to date is a date and formats if not a date
-// the string content is returned, if not set this defaults to 'now'
+// the string content is returned, if not set this defaults to the
+// configured wording of ongoing ranges ('now')
 func (d *CardDateRange) GetToDateAsStr() string {
-	d.setFormat()
-	if date, ok := d.To.(time.Time); ok {
-		return date.Format(d.Format)
+	style := getDateStyle()
+	if date, ok := d.end(); ok {
+		return style.format(date.Time, style.layout(d.Format, date))
 	} else if str, ok := d.To.(string); ok {
 		return str
 	}
-	return "now"
+	return style.ongoing()
 }
 
 // castToCard casts the array of a specific kind to an array of Cards
@@ -118,15 +193,38 @@ func castToCard[T Card](content []T) []Card {
 	return casted
 }
 
-// renderCard renders the passed content as html from its template
-func renderCard(card Card) (template.HTML, error) {
+// castFromCard is the reverse of castToCard, the cards must be of type T
+func castFromCard[T Card](cards []Card) []T {
+	casted := make([]T, len(cards))
+	for idx, crd := range cards {
+		casted[idx] = crd.(T)
+	}
+	return casted
+}
+
+// cardSetter is implemented by the card content configs, to replace their
+// cards with a subset of the ones returned by Elements
+type cardSetter interface {
+	setElements(cards []Card)
+}
+
+// cardTemplatePath returns the path of the card template of t, relative
+// paths are resolved from the content templates dir
+func cardTemplatePath(t *Type) string {
+	if filepath.IsAbs(t.CardTemplate) {
+		return t.CardTemplate
+	}
+	return filepath.Join(config.ContentTemplatesPath(), t.CardTemplate)
+}
+
+// renderCard renders the passed content as html from the template htmlTpl
+func renderCard(card Card, htmlTpl string) (template.HTML, error) {
 
-	contentBaseTpl := filepath.Join(config.ContentTemplatesPath(), "base.html")
-	htmlTpl := filepath.Join(config.ContentTemplatesPath(), card.CardTemplateName())
+	contentBaseTpl := filepath.Join(config.ContentTemplatesPath(), contentBaseTplFile)
 
-	rendered, err := apputils.RenderTemplate("content", card, contentBaseTpl, htmlTpl)
+	rendered, err := apputils.RenderTemplate(cardTplName, card, contentBaseTpl, htmlTpl)
 	if nil != err {
-		log.Printf("[ERROR] Failed to parse template '%s': %s\n", htmlTpl, err)
+		slog.Error("Failed to parse template", "template", htmlTpl, "error", err)
 		return "", err
 	}
 
@@ -134,13 +232,21 @@ func renderCard(card Card) (template.HTML, error) {
 }
 
 // renderCards, a helper method to render all card content types
-func renderCards(obj CardContentConfig, cardType string) (*template.HTML, error) {
+func renderCards(obj CardContentConfig, t *Type) (*template.HTML, error) {
 	// render the content read from yaml into the html models
-	cards := obj.Elements()
+	now := time.Now()
+	cards := publishedCards(obj.Elements(), now)
 	updateCardImages(cards)
+	return renderCardList(sortCards(listedCards(cards, now), t), t, nil)
+}
+
+// renderCardList renders the cards of t in the order given, tag is the tag
+// the cards were filtered by (nil if they were not)
+func renderCardList(cards []Card, t *Type, tag *Tag) (*template.HTML, error) {
+	htmlTpl := cardTemplatePath(t)
 	data := make([]template.HTML, 0)
 	for _, crd := range cards {
-		if tpl, err := renderCard(crd); nil != err {
+		if tpl, err := renderCard(crd, htmlTpl); nil != err {
 			return nil, err
 		} else {
 			data = append(data, tpl)
@@ -150,15 +256,17 @@ func renderCards(obj CardContentConfig, cardType string) (*template.HTML, error)
 	cardData := struct {
 		Type  string
 		Cards []template.HTML
+		Tag   *Tag
 	}{
-		Type:  cardType,
+		Type:  t.Name,
 		Cards: data,
+		Tag:   tag,
 	}
 
 	baseTpl := filepath.Join(config.ContentTemplatesPath(), cardsTpl)
 	rendered, err := apputils.RenderTemplate("cards", &cardData, baseTpl)
 	if err != nil {
-		log.Printf("[ERROR] Failed to render %s\n", baseTpl)
+		slog.Error("Failed to render template", "template", baseTpl)
 		return nil, err
 	}
 	html := template.HTML(rendered)
@@ -166,18 +274,11 @@ func renderCards(obj CardContentConfig, cardType string) (*template.HTML, error)
 	return &html, nil
 }
 
-// imageRef exposes an image field for cache updates.
-type imageRef interface {
-	ImageRef() *string
-}
-
 // updateCardImages rewrites card image URLs to cached paths when enabled.
 func updateCardImages(cards []Card) {
 	for _, card := range cards {
-		if ref, ok := card.(imageRef); ok {
-			if img := ref.ImageRef(); img != nil && *img != "" {
-				*img = apputils.MaybeCacheImage(*img)
-			}
+		if img := card.ImageRef(); img != nil && *img != "" {
+			*img = apputils.MaybeCacheImage(*img)
 		}
 	}
 }
@@ -30,8 +30,18 @@ package content
 
 import "html/template"
 
+func init() {
+	MustRegister(&Type{
+		Name:         "certifications",
+		CardTemplate: "certification.html",
+		Pager:        3,
+		New:          func() ContentConfig { return &CertificationConfig{} },
+	})
+}
+
 type CertificationConfig struct {
-	Certifications []*CertificationCard `yaml:"certifications"`
+	TypeInfo       `yaml:"-" json:"-"`
+	Certifications []*CertificationCard `yaml:"certifications" json:"certifications,omitempty"`
 }
 
 // Make sure the interface is implemented
@@ -42,20 +52,17 @@ func (cc *CertificationConfig) Elements() []Card {
 	return castToCard(cc.Certifications)
 }
 
-func (cc *CertificationConfig) ConfigName() string {
-	return cc.ContentType() + ".yml"
-}
-
-func (cc *CertificationConfig) ContentType() string {
-	return ContentTypes[typeCertification]
+func (cc *CertificationConfig) setElements(cards []Card) {
+	cc.Certifications = castFromCard[*CertificationCard](cards)
 }
 
-func (cc *CertificationConfig) Title() string {
-	return cc.ContentType()
+// Validate checks the slugs of the cards
+func (cc *CertificationConfig) Validate() error {
+	return checkSlugs("certifications", cc.Elements())
 }
 
 func (cc *CertificationConfig) Render() (*template.HTML, error) {
-	return renderCards(cc, cc.ContentType())
+	return renderCards(cc, cc.Type())
 }
 
 type CertificationCard struct {
@@ -65,7 +72,3 @@ type CertificationCard struct {
 
 // Make sure the interface is implemented
 var _ Card = &CertificationCard{}
-
-func (c *CertificationCard) CardTemplateName() string {
-	return "certification.html"
-}
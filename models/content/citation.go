@@ -0,0 +1,276 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The formats the publications can be downloaded in, they are the file
+// extensions as well (e.g. /publications.bib)
+const (
+	// CitationBibTeX is the BibTeX format
+	CitationBibTeX = "bib"
+	// CitationCSLJSON is the CSL-JSON format (https://citeproc-js.readthedocs.io/en/latest/csl-json/markup.html)
+	CitationCSLJSON = "json"
+)
+
+// CitationFormats are all supported citation formats
+var CitationFormats = []string{CitationBibTeX, CitationCSLJSON}
+
+// ErrNoCitations is returned for content types which cannot be downloaded as
+// citations and for unknown formats
+var ErrNoCitations = errors.New("no citations")
+
+// citer is implemented by the content configs whose cards can be downloaded
+// as citations
+type citer interface {
+	CardContentConfig
+	// citations returns the cards encoded in all CitationFormats
+	citations(cards []Card) (map[string][]byte, error)
+}
+
+// GetCitations returns the listed cards of contentType encoded in format (one
+// of CitationFormats)
+func GetCitations(contentType string, format string) ([]byte, error) {
+	rendered, err := getRendered(contentType)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := rendered.citations[format]
+	if !ok {
+		return nil, ErrNoCitations
+	}
+	return data, nil
+}
+
+// citations returns the publications encoded in all CitationFormats
+func (pc *PublicationConfig) citations(cards []Card) (map[string][]byte, error) {
+	publications := make([]*PublicationCard, len(cards))
+	for idx, card := range cards {
+		publications[idx] = card.(*PublicationCard)
+	}
+	keys := citationKeys(publications)
+	bib, err := encodeBibTeX(publications, keys)
+	if err != nil {
+		return nil, err
+	}
+	csl, err := encodeCSLJSON(publications, keys)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		CitationBibTeX:  bib,
+		CitationCSLJSON: csl,
+	}, nil
+}
+
+// citationKeys returns the citation keys of the publications, derived keys
+// which are not unique get a suffix (e.g. pike2012goa, pike2012gob)
+func citationKeys(publications []*PublicationCard) []string {
+	keys := make([]string, len(publications))
+	count := make(map[string]int)
+	for idx, p := range publications {
+		keys[idx] = p.Key
+		if keys[idx] == "" {
+			keys[idx] = deriveCitationKey(p)
+		}
+		count[keys[idx]]++
+	}
+	suffix := make(map[string]rune)
+	for idx, p := range publications {
+		if p.Key != "" || count[keys[idx]] < 2 {
+			continue
+		}
+		if suffix[keys[idx]] == 0 {
+			suffix[keys[idx]] = 'a'
+		}
+		next := suffix[keys[idx]]
+		suffix[keys[idx]]++
+		keys[idx] += string(next)
+	}
+	return keys
+}
+
+// deriveCitationKey returns the last name of the first author, the year and
+// the first word of the title, e.g. pike2012go
+func deriveCitationKey(p *PublicationCard) string {
+	var author, word string
+	if len(p.Authors) > 0 {
+		_, family := splitName(p.Authors[0])
+		author = strings.ReplaceAll(slugify(family), "-", "")
+	}
+	for _, w := range strings.Fields(p.Name) {
+		if w = strings.ReplaceAll(slugify(w), "-", ""); w != "" {
+			word = w
+			break
+		}
+	}
+	return author + strconv.Itoa(p.Year) + word
+}
+
+// splitName splits name into the given and the family name, the family name
+// is the last word (e.g. 'Rob Pike'), or the part before the comma (e.g.
+// 'Pike, Rob')
+func splitName(name string) (given string, family string) {
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(given), strings.TrimSpace(family)
+	}
+	name = strings.TrimSpace(name)
+	if idx := strings.LastIndexFunc(name, unicode.IsSpace); idx >= 0 {
+		return strings.TrimSpace(name[:idx]), name[idx+1:]
+	}
+	return "", name
+}
+
+// bibTeXEscaper escapes the characters with a special meaning in BibTeX
+var bibTeXEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"&", `\&`,
+	"%", `\%`,
+	"$", `\$`,
+	"#", `\#`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+	"^", `\textasciicircum{}`,
+)
+
+// bibTeXField is a field of a BibTeX entry, verbatim fields (doi and url)
+// are written as they are
+type bibTeXField struct {
+	name     string
+	value    string
+	verbatim bool
+}
+
+// balancedBraces returns true if every opening brace in s is closed
+func balancedBraces(s string) bool {
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// encodeBibTeX returns the publications as BibTeX entries
+func encodeBibTeX(publications []*PublicationCard, keys []string) ([]byte, error) {
+	var buf bytes.Buffer
+	for idx, p := range publications {
+		kind := p.kind()
+		fields := []bibTeXField{
+			{name: "title", value: p.Name},
+			{name: "author", value: strings.Join(p.Authors, " and ")},
+			{name: kind.bibVenue, value: p.Venue},
+			{name: "year", value: strconv.Itoa(p.Year)},
+			{name: "doi", value: p.DOI, verbatim: true},
+			{name: "url", value: p.citationURL(), verbatim: true},
+		}
+		if idx > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "@%s{%s,\n", kind.bibType, keys[idx])
+		for _, field := range fields {
+			if field.value == "" {
+				continue
+			}
+			value := field.value
+			if !field.verbatim {
+				value = bibTeXEscaper.Replace(value)
+			} else if !balancedBraces(value) {
+				return nil, fmt.Errorf("publication %s: unbalanced braces in %s '%s'", p.Name, field.name, value)
+			}
+			fmt.Fprintf(&buf, "  %s = {%s},\n", field.name, value)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// cslName is a name in CSL-JSON, names without family name are literal
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// encodeCSLJSON returns the publications as CSL-JSON
+func encodeCSLJSON(publications []*PublicationCard, keys []string) ([]byte, error) {
+	items := make([]map[string]interface{}, len(publications))
+	for idx, p := range publications {
+		kind := p.kind()
+		authors := make([]cslName, len(p.Authors))
+		for aIdx, author := range p.Authors {
+			if given, family := splitName(author); given == "" {
+				authors[aIdx] = cslName{Literal: family}
+			} else {
+				authors[aIdx] = cslName{Family: family, Given: given}
+			}
+		}
+		item := map[string]interface{}{
+			"id":     keys[idx],
+			"type":   kind.cslType,
+			"title":  p.Name,
+			"author": authors,
+			"issued": map[string][][]int{"date-parts": {{p.Year}}},
+		}
+		for key, value := range map[string]string{kind.cslVenue: p.Venue, "DOI": p.DOI, "URL": p.citationURL()} {
+			if value != "" {
+				item[key] = value
+			}
+		}
+		items[idx] = item
+	}
+	return json.MarshalIndent(items, "", "  ")
+}
+
+// citationURL returns the link of the publication or its pdf, links
+// relative to the site are left out as they cannot be resolved by others
+func (p *PublicationCard) citationURL() string {
+	for _, link := range []string{p.Link, p.PDF} {
+		if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+			return link
+		}
+	}
+	return ""
+}
@@ -31,42 +31,57 @@ package content
 import (
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/bossm8/portfoli.go/config"
 	apputils "github.com/bossm8/portfoli.go/utils"
 
 	"github.com/bossm8/portfoli.go/models/utils"
 )
 
-const (
-	typeExperience = iota
-	typeEducation
-	typeProject
-	typeCertification
-	typeAbout
-)
-
 var (
-	// All possible content types
-	ContentTypes = []string{"experience", "education", "projects", "certifications", "bio"}
-	// Mappings to easily get the correct content type based on the request path
-	contentMappings = map[string]ContentConfig{
-		ContentTypes[typeExperience]:    &ExperienceConfig{},
-		ContentTypes[typeEducation]:     &EducationConfig{},
-		ContentTypes[typeProject]:       &ProjectConfig{},
-		ContentTypes[typeCertification]: &CertificationConfig{},
-		ContentTypes[typeAbout]:         &AboutMeConfig{},
-	}
-	// Regex which contains all possible content types
-	rex = regexp.MustCompile(fmt.Sprintf("(%s)", strings.Join(ContentTypes, "|")))
+	renderedContentMu sync.Mutex
+	// renderedContent caches the rendered content by content type
+	renderedContent = make(map[string]*renderedType)
 )
 
+// renderedType is the rendered content of a content type
+type renderedType struct {
+	content *ContentTemplateData
+	// details are the detail pages of the cards having one, in list order
+	details []*CardDetailData
+	// tags are the tags of the cards, sorted by name
+	tags []*Tag
+	// tagged contains the content listing only the cards with a tag, by
+	// the slug of the tag
+	tagged map[string]*ContentTemplateData
+	// citations contains the listed cards by citation format, nil if the
+	// content type cannot be downloaded as citations
+	citations map[string][]byte
+	// validUntil is the point in time when a card is published or expires
+	// or the durations of the date ranges change, the content must be
+	// rendered again then, zero if there is none
+	validUntil time.Time
+}
+
+// dependentContent is implemented by content rendering the cards of other
+// content types (e.g. the skills), it has to be rendered again when they change
+type dependentContent interface {
+	nextContentChange() time.Time
+}
+
 // ContentTemplateData the data which must be passed to the content html templates
 type ContentTemplateData struct {
 	Title string
 	HTML  *template.HTML
+	// Tag is the tag the cards are filtered by, nil if they are not
+	Tag *Tag
 	// Prev and Next are the content type slugs to link to as
 	// previous/next at the bottom of the page (see GetPagerLinks), empty
 	// when there is no link on that side
@@ -74,45 +89,31 @@ type ContentTemplateData struct {
 	Next string
 }
 
-// pagerContentTypes is the fixed order experience/education/certifications/
-// projects are linked to each other at the bottom of their pages (see
-// GetPagerLinks). Independent of the order the site owner lists them in
-// `content:`. bio is intentionally not part of this chain.
-var pagerContentTypes = []string{
-	ContentTypes[typeExperience],
-	ContentTypes[typeEducation],
-	ContentTypes[typeCertification],
-	ContentTypes[typeProject],
-}
-
 // GetPagerLinks returns the content type slugs to link to as
-// previous/next at the bottom of contentType's page. Only pagerContentTypes
-// are ever linked, and any type not present in enabledTypes is skipped so
-// the pager only ever connects pages that actually exist - "" means no
-// link on that side (e.g. contentType isn't part of the pager sequence, or
-// it's the first/last enabled entry).
+// previous/next at the bottom of contentType's page. Only types registered
+// with a pager position are ever linked (ordered by it, independent of the
+// order the site owner lists them in `content:`), and any type not present
+// in enabledTypes is skipped so the pager only ever connects pages that
+// actually exist - "" means no link on that side (e.g. contentType isn't
+// part of the pager sequence, or it's the first/last enabled entry).
 func GetPagerLinks(contentType string, enabledTypes []string) (prev string, next string) {
-	enabled := make(map[string]bool, len(enabledTypes))
-	for _, t := range enabledTypes {
-		enabled[t] = true
-	}
-
-	var sequence []string
-	for _, t := range pagerContentTypes {
-		if enabled[t] {
+	var sequence []*Type
+	for _, name := range enabledTypes {
+		if t, ok := Lookup(name); ok && t.Pager > 0 && !slices.Contains(sequence, t) {
 			sequence = append(sequence, t)
 		}
 	}
+	slices.SortStableFunc(sequence, func(a, b *Type) int { return a.Pager - b.Pager })
 
 	for i, t := range sequence {
-		if t != contentType {
+		if t.Name != contentType {
 			continue
 		}
 		if i > 0 {
-			prev = sequence[i-1]
+			prev = sequence[i-1].Name
 		}
 		if i < len(sequence)-1 {
-			next = sequence[i+1]
+			next = sequence[i+1].Name
 		}
 		return
 	}
@@ -127,6 +128,10 @@ type ContentConfig interface {
 	ContentType() string
 	// Title returns the title of the content which can be used in the templates
 	Title() string
+	// SetType sets the registered content type the config belongs to
+	SetType(t *Type)
+	// Type returns the registered content type the config belongs to
+	Type() *Type
 	// Render returns the rendered html to be placed in the content template
 	Render() (*template.HTML, error)
 }
@@ -134,20 +139,106 @@ type ContentConfig interface {
 // GetRenderedContent reads the content kind passed from its yaml configuration
 // and returns all configured elements as html to be placed in the main
 // template directly
+// The rendered content is cached unless the development mode is enabled
 func GetRenderedContent(contentType string) (*ContentTemplateData, error) {
-	// Get the correct object to load
-	// TODO validate so we do not have null values
-	obj := contentMappings[contentType]
+	rendered, err := getRendered(contentType)
+	if err != nil {
+		return nil, err
+	}
+	// return a copy, callers set e.g. the pager links on it
+	cp := *rendered.content
+	return &cp, nil
+}
+
+// GetCardDetails returns the data of the detail pages of all cards of
+// contentType which have one (see CardBase.Body), in the order they are listed
+func GetCardDetails(contentType string) ([]*CardDetailData, error) {
+	rendered, err := getRendered(contentType)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.details, nil
+}
 
-	err := loadContentConfig(obj)
+// GetCardDetail returns the data of the detail page of the card of
+// contentType with slug, ErrCardNotFound if there is none
+func GetCardDetail(contentType string, slug string) (*CardDetailData, error) {
+	details, err := GetCardDetails(contentType)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		if detail.Card.Base().GetSlug() == slug {
+			return detail, nil
+		}
+	}
+	return nil, ErrCardNotFound
+}
+
+// getRendered returns the (cached) rendered content of contentType
+func getRendered(contentType string) (*renderedType, error) {
+	if !apputils.DevMode() {
+		renderedContentMu.Lock()
+		defer renderedContentMu.Unlock()
+		if rendered, ok := renderedContent[contentType]; ok &&
+			(rendered.validUntil.IsZero() || time.Now().Before(rendered.validUntil)) {
+			return rendered, nil
+		}
+	}
+
+	rendered, err := renderContent(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if !apputils.DevMode() {
+		renderedContent[contentType] = rendered
+	}
+	return rendered, nil
+}
+
+// ResetRendered empties the cache of rendered content, the returned function
+// restores the previous state
+func ResetRendered() (restore func()) {
+	renderedContentMu.Lock()
+	defer renderedContentMu.Unlock()
+	prev := renderedContent
+	renderedContent = make(map[string]*renderedType)
+	return func() {
+		renderedContentMu.Lock()
+		defer renderedContentMu.Unlock()
+		renderedContent = prev
+	}
+}
+
+// RenderAll renders (and caches) all given content types, it returns the
+// first error encountered
+func RenderAll(contentTypes []string) error {
+	for _, contentType := range contentTypes {
+		if _, err := GetRenderedContent(contentType); err != nil {
+			return fmt.Errorf("rendering %s: %w", contentType, err)
+		}
+	}
+	return nil
+}
+
+// renderContent loads and renders the content of contentType, including the
+// detail pages of its cards
+func renderContent(contentType string) (*renderedType, error) {
+	now := time.Now()
+	obj, err := New(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = loadContentConfig(obj)
 	if nil != err {
-		log.Printf("[ERROR] Loading content failed: %s\n", err)
+		slog.Error("Loading content failed", "content", contentType, "error", err)
 		return nil, err
 	}
 
 	data, err := obj.Render()
 	if err != nil {
-		log.Printf("[ERROR] Failed to render content for %s: %s\n", contentType, err)
+		slog.Error("Failed to render content", "content", contentType, "error", err)
 		return nil, err
 	}
 
@@ -155,16 +246,105 @@ func GetRenderedContent(contentType string) (*ContentTemplateData, error) {
 	// be used in the html configuration
 	data, err = apputils.ProcessHTMLContent(data)
 	if err != nil {
-		log.Printf("[ERROR] HTML content prossecing of %s failed\n", contentType)
+		slog.Error("HTML content processing failed", "content", contentType)
+		return nil, err
+	}
+
+	rendered := &renderedType{content: &ContentTemplateData{Title: obj.Title(), HTML: data}}
+	if cards, ok := obj.(CardContentConfig); ok {
+		rendered.validUntil = nextPublishingChange(cards.Elements(), now)
+		if day := nextDateChange(cards.Elements(), now); !day.IsZero() &&
+			(rendered.validUntil.IsZero() || day.Before(rendered.validUntil)) {
+			rendered.validUntil = day
+		}
+		if err := renderTagged(rendered, cards, obj.Type(), now); err != nil {
+			slog.Error("Failed to render the tagged content", "content", contentType, "error", err)
+			return nil, err
+		}
+		if rendered.details, err = renderDetails(cards, obj.Type(), now); err != nil {
+			slog.Error("Failed to render the detail pages", "content", contentType, "error", err)
+			return nil, err
+		}
 	}
+	if dependent, ok := obj.(dependentContent); ok {
+		if next := dependent.nextContentChange(); !next.IsZero() &&
+			(rendered.validUntil.IsZero() || next.Before(rendered.validUntil)) {
+			rendered.validUntil = next
+		}
+	}
+	if citer, ok := obj.(citer); ok {
+		listed := sortCards(listedCards(citer.Elements(), now), obj.Type())
+		if rendered.citations, err = citer.citations(listed); err != nil {
+			slog.Error("Failed to encode the citations", "content", contentType, "error", err)
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
 
-	return &ContentTemplateData{Title: obj.Title(), HTML: data}, err
+// renderTagged renders the content of t listing only the cards with a tag for
+// every tag of the cards of obj listed at now into rendered
+func renderTagged(rendered *renderedType, obj CardContentConfig, t *Type, now time.Time) error {
+	var tagged map[string][]Card
+	rendered.tags, tagged = collectTags(sortCards(listedCards(obj.Elements(), now), t))
+	rendered.tagged = make(map[string]*ContentTemplateData, len(rendered.tags))
+	for _, tag := range rendered.tags {
+		data, err := renderCardList(tagged[tag.Slug], t, tag)
+		if err == nil {
+			data, err = apputils.ProcessHTMLContent(data)
+		}
+		if err != nil {
+			return fmt.Errorf("tag %s: %w", tag.Name, err)
+		}
+		rendered.tagged[tag.Slug] = &ContentTemplateData{Title: rendered.content.Title, HTML: data, Tag: tag}
+	}
+	return nil
+}
+
+// TemplateSets returns the template sets used to render the content, so
+// they can be preloaded (see utils.PreloadTemplates)
+func TemplateSets() ([]apputils.TemplateSet, error) {
+	files, err := filepath.Glob(filepath.Join(config.ContentTemplatesPath(), "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	contentBaseTpl := filepath.Join(config.ContentTemplatesPath(), contentBaseTplFile)
+	var sets []apputils.TemplateSet
+	cardTemplates := make(map[string]bool)
+	for _, t := range types() {
+		if t.CardTemplate == "" {
+			continue
+		}
+		// card templates, rendered together with the content base
+		tpl := cardTemplatePath(t)
+		cardTemplates[tpl] = true
+		sets = append(sets, apputils.TemplateSet{
+			Name:  cardTplName,
+			Files: []string{contentBaseTpl, tpl},
+		})
+	}
+	for _, file := range files {
+		if file == contentBaseTpl || cardTemplates[file] {
+			continue
+		}
+		// e.g. cards.html and bio.html, rendered on their own, with the file
+		// name as template name
+		sets = append(sets, apputils.TemplateSet{
+			Name:  strings.TrimSuffix(filepath.Base(file), ".html"),
+			Files: []string{file},
+		})
+	}
+	return sets, nil
 }
 
 // GetRoutingRegexString returns the regex which catches the endpoints for
-// the content types as string
+// the registered content types as string
 func GetRoutingRegexString() string {
-	return rex.String()
+	names := TypeNames()
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(names, "|"))
 }
 
 // unmarshallContentConfig
@@ -174,8 +354,27 @@ func unmarshalContentConfig(content ContentConfig) error {
 
 // loadContentConfig loads the specified content from it's yaml file
 func loadContentConfig(content ContentConfig) error {
-	err := unmarshalContentConfig(content)
-	return err
+	if err := unmarshalContentConfig(content); err != nil {
+		return err
+	}
+	if val, ok := content.(utils.Validator); ok {
+		if err := val.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", content.ConfigName(), err)
+		}
+	}
+	if prep, ok := content.(preparer); ok {
+		prep.prepare()
+	}
+	if cards, ok := content.(CardContentConfig); ok {
+		prepareCards(cards.Elements(), content.Type())
+	}
+	return nil
+}
+
+// preparer is implemented by content configs deriving values from the loaded
+// ones, e.g. the date range of experience entries from their roles
+type preparer interface {
+	prepare()
 }
 
 // PrefetchImages loads content configs and caches remote images if configured.
@@ -184,27 +383,62 @@ func PrefetchImages(contentTypes []string) {
 		if !IsValidContentType(contentType) {
 			continue
 		}
-		obj := contentMappings[contentType]
-		if obj == nil {
+		obj, err := New(contentType)
+		if err != nil {
 			continue
 		}
 		if err := loadContentConfig(obj); err != nil {
-			log.Printf("[WARNING] Prefetching content %s failed: %s\n", contentType, err)
+			slog.Warn("Prefetching content failed", "content", contentType, "error", err)
 			continue
 		}
 		if cards, ok := obj.(CardContentConfig); ok {
-			updateCardImages(cards.Elements())
+			updateCardImages(publishedCards(cards.Elements(), time.Now()))
+		}
+	}
+}
+
+// CheckContent loads the yaml configuration of contentType into a new object
+// (leaving the one used for rendering untouched) to check if it is valid
+func CheckContent(contentType string) error {
+	_, err := Load(contentType)
+	return err
+}
+
+// Load loads the yaml configuration of contentType into a new object and
+// returns it without rendering, e.g. to export the content
+func Load(contentType string) (ContentConfig, error) {
+	obj, err := New(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadContentConfig(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// LoadPublished is the same as Load, but only keeps the cards published at
+// the moment (all of them if drafts are previewed)
+func LoadPublished(contentType string) (ContentConfig, error) {
+	obj, err := Load(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if cards, ok := obj.(CardContentConfig); ok {
+		setter, ok := obj.(cardSetter)
+		if !ok {
+			return nil, fmt.Errorf("content type %s cannot filter its cards", contentType)
 		}
+		setter.setElements(publishedCards(cards.Elements(), time.Now()))
 	}
+	return obj, nil
 }
 
-// IsValidContentType returns if the content type passed is a valid one
+// IsValidContentType returns if the content type passed is a registered one
 func IsValidContentType(contentType string) bool {
-	isValid := true
-	// Check if all content kinds specified in the yaml config are valid
-	if !rex.MatchString(contentType) {
-		log.Printf("[ERROR] Invalid content kind '%s', allowed values are: %s\n", contentType, ContentTypes)
-		isValid = false
+	if _, ok := Lookup(contentType); !ok {
+		slog.Error("Invalid content kind", "content", contentType, "allowed", TypeNames())
+		return false
 	}
-	return isValid
+	return true
 }
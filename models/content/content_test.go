@@ -28,8 +28,548 @@
 
 package content
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
 
 func TestRegex(t *testing.T) {
+	rex := regexp.MustCompile("^" + GetRoutingRegexString() + "$")
+	for _, name := range []string{"experience", "education", "projects", "certifications", "bio"} {
+		if !rex.MatchString(name) {
+			t.Errorf("expected %s to be routed", name)
+		}
+	}
+	for _, name := range []string{"experiences", "about", ""} {
+		if rex.MatchString(name) {
+			t.Errorf("expected %s not to be routed", name)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	talks := &Type{Name: "talks", CardTemplate: "project.html", Pager: 5, New: func() ContentConfig { return &ProjectConfig{} }}
+	if err := Register(talks); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		registryMu.Lock()
+		delete(registry, talks.Name)
+		registryMu.Unlock()
+	}()
+
+	if talks.File != "talks.yml" || talks.Title != "talks" {
+		t.Errorf("expected defaults for file and title, got %s and %s", talks.File, talks.Title)
+	}
+	if err := Register(&Type{Name: "talks", New: talks.New}); err == nil {
+		t.Error("expected an error when registering a name twice")
+	}
+	if err := Register(&Type{Name: "Not Valid", New: talks.New}); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+	if !IsValidContentType("talks") {
+		t.Error("expected the registered type to be valid")
+	}
+	obj, err := New("talks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.ConfigName() != "talks.yml" || obj.ContentType() != "talks" {
+		t.Errorf("expected the config to know its type, got %s", obj.ContentType())
+	}
+	if prev, next := GetPagerLinks("talks", []string{"bio", "projects", "talks"}); prev != "projects" || next != "" {
+		t.Errorf("expected talks to follow projects, got %q and %q", prev, next)
+	}
+}
+
+func TestGetPagerLinks(t *testing.T) {
+	enabled := []string{"bio", "projects", "experience", "certifications"}
+	tests := []struct {
+		contentType string
+		prev, next  string
+	}{
+		{"experience", "", "certifications"},
+		{"certifications", "experience", "projects"},
+		{"projects", "certifications", ""},
+		{"bio", "", ""},
+		{"education", "", ""},
+	}
+	for _, test := range tests {
+		prev, next := GetPagerLinks(test.contentType, enabled)
+		if prev != test.prev || next != test.next {
+			t.Errorf("%s: expected %q/%q, got %q/%q", test.contentType, test.prev, test.next, prev, next)
+		}
+	}
+}
+
+func TestSetCustomTypes(t *testing.T) {
+	defer SetCustomTypes(nil)
+
+	if err := SetCustomTypes([]*CustomType{{Name: "talks"}, {Name: "open-source", Pager: 9}}); err != nil {
+		t.Fatal(err)
+	}
+	talks, ok := Lookup("talks")
+	if !ok || talks.CardTemplate != genericCardTpl || talks.File != "talks.yml" {
+		t.Fatalf("expected talks to be registered with the defaults, got %+v", talks)
+	}
+	if obj, err := New("open-source"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := obj.(*GenericConfig); !ok {
+		t.Errorf("expected a generic config, got %T", obj)
+	}
+
+	// replacing removes the previous custom types
+	if err := SetCustomTypes([]*CustomType{{Name: "talks"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Lookup("open-source"); ok {
+		t.Error("expected open-source to be removed")
+	}
+
+	// built-in names are taken, nothing must change on errors
+	if err := SetCustomTypes([]*CustomType{{Name: "volunteering"}, {Name: "projects"}}); err == nil {
+		t.Error("expected an error when declaring a built-in type")
+	}
+	if _, ok := Lookup("volunteering"); ok {
+		t.Error("expected no change after an error")
+	}
+	if _, ok := Lookup("talks"); !ok {
+		t.Error("expected talks to be kept after an error")
+	}
+
+	// built-in types added later are replaced by custom ones of the same name
+	if err := SetCustomTypes([]*CustomType{{Name: "publications"}}); err != nil {
+		t.Fatal(err)
+	}
+	if obj, err := New("publications"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := obj.(*GenericConfig); !ok {
+		t.Errorf("expected the custom type to take precedence, got %T", obj)
+	}
+	if err := SetCustomTypes([]*CustomType{{Name: "publications"}, {Name: "publications"}}); err == nil {
+		t.Error("expected an error when declaring a type twice")
+	}
+	if err := SetCustomTypes(nil); err != nil {
+		t.Fatal(err)
+	}
+	if obj, err := New("publications"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := obj.(*PublicationConfig); !ok {
+		t.Errorf("expected the built-in type to be restored, got %T", obj)
+	}
+
+	// names of routes and pages of the server are reserved
+	for _, name := range []string{"contact", "tags", "healthz"} {
+		err := SetCustomTypes([]*CustomType{{Name: "talks"}, {Name: name}})
+		if keyErr := (&utils.KeyError{}); !errors.As(err, &keyErr) || keyErr.Key != "customcontent.1.name" {
+			t.Errorf("expected the reserved name %s to be reported, got %v", name, err)
+		}
+	}
+	if err := (&CustomType{Name: "index"}).Validate(); err == nil {
+		t.Error("expected validate to report a reserved name")
+	}
+}
+
+func TestSlugs(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Portfoli.go":                 "portfoli-go",
+		"  Renée's Gopher Artwork!  ": "renees-gopher-artwork",
+		"C++ / Go":                    "c-go",
+	} {
+		if slug := slugify(name); slug != expected {
+			t.Errorf("expected slug %q for %q, got %q", expected, name, slug)
+		}
+	}
+
+	projects := &ProjectConfig{Projects: []*ProjectCard{
+		{CardBase: CardBase{Name: "Portfoli.go", Body: "write-up"}},
+		{CardBase: CardBase{Name: "Portfoli Go"}},
+		{CardBase: CardBase{Name: "Other", Slug: "portfoli-go", Body: "write-up"}},
+	}}
+	err := projects.Validate()
+	if keyErr := (&utils.KeyError{}); !errors.As(err, &keyErr) || keyErr.Key != "projects.2.slug" {
+		t.Errorf("expected the duplicate slug of a card with a body to be reported, got %v", err)
+	}
+
+	projects.Projects[2].Slug = "../other"
+	if err := projects.Validate(); err == nil {
+		t.Error("expected an invalid slug to be reported")
+	}
+
+	projects.Projects[2].Slug = "other"
+	if err := projects.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	prepareCards(projects.Elements(), &Type{Name: "projects"})
+	for idx, expected := range []string{"/projects/portfoli-go", "", "/projects/other"} {
+		if path := projects.Projects[idx].DetailPath(); path != expected {
+			t.Errorf("expected detail path %q for card %d, got %q", expected, idx, path)
+		}
+	}
+}
+
+func TestSortCards(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	experience := &ExperienceConfig{Experiences: []*ExperienceCard{
+		{CardBase: CardBase{Name: "b"}, CardDateRange: CardDateRange{From: Date{Time: date("2020-01-01")}, To: date("2021-01-01")}},
+		{CardBase: CardBase{Name: "c"}, CardDateRange: CardDateRange{From: Date{Time: date("2019-01-01")}, To: "now"}},
+		{CardBase: CardBase{Name: "a"}, CardDateRange: CardDateRange{From: Date{Time: date("2021-01-01")}, To: date("2022-01-01")}},
+		{CardBase: CardBase{Name: "d", Pinned: true}, CardDateRange: CardDateRange{From: Date{Time: date("2010-01-01")}, To: date("2011-01-01")}},
+	}}
+	typ := &Type{Name: "experience"}
+	defer SetSortOrders(nil)
+
+	for _, test := range []struct {
+		order    *SortOrder
+		expected string
+	}{
+		{nil, "dbca"},
+		{&SortOrder{By: SortManual, Order: "desc"}, "dacb"},
+		{&SortOrder{By: SortName}, "dabc"},
+		{&SortOrder{By: SortFrom}, "dabc"},
+		{&SortOrder{By: SortFrom, Order: "asc"}, "dcba"},
+		// the range which has not ended yet is the latest
+		{&SortOrder{By: SortTo}, "dcab"},
+	} {
+		if err := SetSortOrders(map[string]*SortOrder{"experience": test.order}); err != nil {
+			t.Fatal(err)
+		}
+		var names string
+		for _, card := range sortCards(experience.Elements(), typ) {
+			names += card.Base().Name
+		}
+		if names != test.expected {
+			t.Errorf("expected order %s for %+v, got %s", test.expected, test.order, names)
+		}
+	}
+
+	if err := SetSortOrders(map[string]*SortOrder{"experience": {By: "date"}}); err == nil {
+		t.Error("expected an unknown sort key to be rejected")
+	}
+}
+
+func TestCollectTags(t *testing.T) {
+	cards := castToCard([]*ProjectCard{
+		{CardBase: CardBase{Name: "a", Tags: []string{"Go", "web"}}},
+		{CardBase: CardBase{Name: "b", Tags: []string{"go", "GO"}}},
+		{CardBase: CardBase{Name: "c"}},
+	})
+	tags, tagged := collectTags(cards)
+	if len(tags) != 2 || tags[0].Name != "Go" || tags[0].Slug != "go" || tags[0].Count != 2 || tags[1].Slug != "web" || tags[1].Count != 1 {
+		t.Errorf("expected the tags go (2) and web (1), got %v %v", tags[0], tags[1:])
+	}
+	if len(tagged["go"]) != 2 || tagged["go"][0] != cards[0] || tagged["go"][1] != cards[1] {
+		t.Errorf("expected the cards a and b to be tagged with go, got %v", tagged["go"])
+	}
+}
+
+func TestPublished(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cards := castToCard([]*ProjectCard{
+		{CardBase: CardBase{Name: "public"}},
+		{CardBase: CardBase{Name: "draft", Draft: true}},
+		{CardBase: CardBase{Name: "hidden", Hidden: true}},
+		{CardBase: CardBase{Name: "scheduled", PublishAfter: now.Add(48 * time.Hour)}},
+		{CardBase: CardBase{Name: "expired", ExpireAfter: now.Add(-time.Hour)}},
+		{CardBase: CardBase{Name: "expiring", PublishAfter: now.Add(-time.Hour), ExpireAfter: now.Add(24 * time.Hour)}},
+	})
+	names := func(cards []Card) (res string) {
+		for _, card := range cards {
+			res += card.Base().Name + " "
+		}
+		return
+	}
+
+	if res := names(publishedCards(cards, now)); res != "public hidden expiring " {
+		t.Errorf("expected drafts, scheduled and expired cards not to be published, got %s", res)
+	}
+	if res := names(listedCards(cards, now)); res != "public expiring " {
+		t.Errorf("expected hidden cards not to be listed, got %s", res)
+	}
+	if next := nextPublishingChange(cards, now); !next.Equal(now.Add(24 * time.Hour)) {
+		t.Errorf("expected the content to change when the card expires, got %s", next)
+	}
+
+	// the export only keeps the published cards in the config
+	var projects cardSetter = &ProjectConfig{}
+	projects.setElements(publishedCards(cards, now))
+	if res := names(projects.(CardContentConfig).Elements()); res != "public hidden expiring " {
+		t.Errorf("expected the config to contain the published cards only, got %s", res)
+	}
+
+	SetPreviewDrafts(true)
+	defer SetPreviewDrafts(false)
+	if res := names(listedCards(cards, now)); res != "public draft scheduled expired expiring " {
+		t.Errorf("expected all cards but the hidden one when previewing drafts, got %s", res)
+	}
+}
+
+func TestDates(t *testing.T) {
+	var experience ExperienceConfig
+	err := yaml.Unmarshal([]byte(`
+experiences:
+  - {name: a, company: x, from: 2020-01-15, to: 2020-03-14}
+  - {name: b, company: x, from: 2021-03, to: 2022-05}
+  - {name: c, company: x, from: 2022-01, to: 2022}
+  - {name: d, company: y, from: 2019, to: now}
+`), &experience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c, d := experience.Experiences[0], experience.Experiences[1], experience.Experiences[2], experience.Experiences[3]
+	if b.From.Precision != PrecisionMonth || d.From.Precision != PrecisionYear || a.From.Precision != PrecisionDay {
+		t.Errorf("expected the precisions day, month and year, got %v %v %v", a.From.Precision, b.From.Precision, d.From.Precision)
+	}
+	if err := yaml.Unmarshal([]byte("from: March"), &CardDateRange{}); err == nil {
+		t.Error("expected an invalid date to fail")
+	}
+	var quoted CardDateRange
+	if err := yaml.Unmarshal([]byte(`{from: "2020-01-15", to: "2020-03-14"}`), &quoted); err != nil {
+		t.Fatal(err)
+	}
+	if quoted.Ongoing() || quoted.From.Precision != PrecisionDay || quoted.GetToDateAsStr() != "2020-03-14" || quoted.Duration() != "2 mos" {
+		t.Errorf("expected quoted dates to be parsed with the precision of a day, got %s to %s", quoted.GetFromDateAsStr(), quoted.GetToDateAsStr())
+	}
+
+	for card, expected := range map[*ExperienceCard]string{a: "2 mos", b: "1 yr 3 mos", c: "1 yr"} {
+		if res := card.Duration(); res != expected {
+			t.Errorf("expected %s to last %s, got %s", card.Name, expected, res)
+		}
+	}
+	if !d.Ongoing() || b.Ongoing() {
+		t.Error("expected only the range ending with a text to be ongoing")
+	}
+	if res := b.GetFromDateAsStr() + " " + b.GetToDateAsStr() + " " + c.GetToDateAsStr(); res != "2021-03 2022-05 2022" {
+		t.Errorf("expected the dates to be formatted with their precision, got %s", res)
+	}
+
+	// the roles b and c overlap from 2022-01 to 2022-05
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	setCompanyTenures(experience.Experiences, now)
+	if a.CompanyTenure() != "2 yrs" || c.CompanyTenure() != "2 yrs" || d.CompanyTenure() != "" {
+		t.Errorf("expected the tenure of x only, got %q %q", a.CompanyTenure(), d.CompanyTenure())
+	}
+
+	if err := SetDateConfig(&DateConfig{Locale: "de-CH", MonthFormat: "January 2006", Format: "2. Jan 2006"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDateConfig(nil)
+	if res := b.GetFromDateAsStr() + ", " + a.GetFromDateAsStr() + ", " + d.GetToDateAsStr(); res != "März 2021, 15. Jan 2020, now" {
+		t.Errorf("expected german month names, got %s", res)
+	}
+	style := getDateStyle()
+	if res := style.ago(b.From.Time, now) + ", " + style.duration(25); res != "vor 3 Jahren, 2 Jahre 1 Monat" {
+		t.Errorf("expected german relative dates and durations, got %s", res)
+	}
+	if err := SetDateConfig(&DateConfig{Locale: "ja"}); err == nil {
+		t.Error("expected an unsupported locale to fail")
+	}
+}
+
+func TestExperienceRoles(t *testing.T) {
+	var experience ExperienceConfig
+	err := yaml.Unmarshal([]byte(`
+experiences:
+  - name: a
+    company: x
+    roles:
+      - {name: senior, from: 2021-01, to: present}
+      - {name: junior, from: 2019-06-15, to: 2020-12-31}
+  - name: b
+    company: y
+    roles:
+      - {name: senior, from: 2020-06, to: 2021-03}
+      - {name: junior, from: 2018, to: 2019}
+`), &experience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	experience.prepare()
+	a, b := experience.Experiences[0], experience.Experiences[1]
+	if err := a.Validate(); err != nil {
+		t.Errorf("expected the range to be optional with roles, got %v", err)
+	}
+	if res := a.GetFromDateAsStr() + " " + a.GetToDateAsStr(); res != "2019-06-15 present" {
+		t.Errorf("expected the range to span the roles and to be ongoing, got %s", res)
+	}
+	if res := b.GetFromDateAsStr() + " " + b.GetToDateAsStr(); res != "2018 2021-03" {
+		t.Errorf("expected the range to span the roles, got %s", res)
+	}
+
+	// the roles of b have a gap from 2020-01 to 2020-05
+	setCompanyTenures(experience.Experiences, time.Now())
+	if res := b.CompanyTenure(); res != "2 yrs 10 mos" {
+		t.Errorf("expected the tenure to leave out the gap, got %s", res)
+	}
+	if res := b.CompanyTenureText(); res != "2 yrs 10 mos at y in total" {
+		t.Errorf("expected the tenure in english, got %s", res)
+	}
+	if err := SetDateConfig(&DateConfig{Locale: "de"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDateConfig(nil)
+	setCompanyTenures(experience.Experiences, time.Now())
+	if res := b.CompanyTenureText(); res != "insgesamt 2 Jahre 10 Monate bei y" {
+		t.Errorf("expected the tenure in german, got %s", res)
+	}
+}
+
+func TestSkills(t *testing.T) {
+	var skills SkillsConfig
+	err := yaml.Unmarshal([]byte(`
+categories:
+  - name: languages
+    skills:
+      - {id: go, name: Go, level: expert, years: 5}
+      - {id: html, name: HTML}
+`), &skills)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := skills.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rank := skills.lookup("go").Rank(); rank != 4 {
+		t.Errorf("expected expert to be the highest rank, got %d", rank)
+	}
+	if res := skills.lookup("go").Experience(); res != "5 yrs" {
+		t.Errorf("expected 5 yrs of experience, got %s", res)
+	}
+	if err := (&Skill{ID: "go", Name: "Go", Level: "guru"}).Validate(); err == nil {
+		t.Error("expected an unknown level to fail")
+	}
+
+	skills.Categories[0].Skills[1].ID = "go"
+	var keyErr *utils.KeyError
+	if err := skills.Validate(); !errors.As(err, &keyErr) || keyErr.Key != "categories.0.skills.1.id" {
+		t.Errorf("expected the duplicate id to be reported, got %v", err)
+	}
+
+	projects := &ProjectConfig{Projects: []*ProjectCard{
+		{CardBase: CardBase{Name: "a"}, SkillRefs: SkillRefs{Skills: []string{"go", "rust"}}},
+	}}
+	errs := CheckSkillRefs(projects, &skills)
+	if len(errs) != 1 || !errors.As(errs[0], &keyErr) || keyErr.Key != "projects.0.skills.1" {
+		t.Errorf("expected the unknown skill rust to be reported, got %v", errs)
+	}
+
+	// only the cards of enabled content types are linked
+	dir := t.TempDir()
+	utils.SetYAMLDir(dir)
+	defer utils.SetYAMLDir("")
+	now := time.Now()
+	publishAfter := now.Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	for file, data := range map[string]string{
+		"projects.yml": "projects:\n  - {name: portfolio, skills: [html]}\n" +
+			"  - {name: scheduled, skills: [html], publishafter: " + publishAfter + "}\n",
+		"experience.yml": "experiences:\n  - {name: dev, company: x, from: 2020, skills: [html]}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skills.Categories[0].Skills[1].ID = "html"
+	if err := skills.setSkillUsages(now, []string{"skills", "projects"}); err != nil {
+		t.Fatal(err)
+	}
+	if usages := skills.lookup("html").Usages(); len(usages) != 1 || usages[0].Name != "portfolio" {
+		t.Errorf("expected the published project only, got %v", usages)
+	}
+	if next := skills.nextContentChange(); next.Format(time.RFC3339) != publishAfter {
+		t.Errorf("expected the skills to change when the project is published, got %s", next)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	publications := []*PublicationCard{
+		{CardBase: CardBase{Name: "The Go Programming Language"}, Authors: []string{"Alan Donovan", "Kernighan, Brian"}, Year: 2015, Kind: "book", Venue: "Addison-Wesley"},
+		{CardBase: CardBase{Name: "Go at Google", Link: "https://go.dev/talks/2012/splash.article"}, Authors: []string{"Rob Pike"}, Year: 2012, Venue: "SPLASH & co_op"},
+		{CardBase: CardBase{Name: "Go, again"}, Authors: []string{"Rob Pike"}, Year: 2012},
+		{CardBase: CardBase{Name: "Gophers"}, Authors: []string{"Renée"}, Year: 2020, Key: "gophers"},
+	}
+	if err := (&PublicationCard{Kind: "poem"}).Validate(); err == nil {
+		t.Error("expected an unknown type to fail")
+	}
+
+	keys := citationKeys(publications)
+	if res := strings.Join(keys, " "); res != "donovan2015the pike2012goa pike2012gob gophers" {
+		t.Errorf("expected derived and unique keys, got %s", res)
+	}
+
+	data, err := encodeBibTeX(publications, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bib := string(data)
+	for _, expected := range []string{
+		"@book{donovan2015the,\n",
+		"  author = {Alan Donovan and Kernighan, Brian},\n",
+		"  publisher = {Addison-Wesley},\n",
+		"@article{pike2012goa,\n",
+		"  journal = {SPLASH \\& co\\_op},\n",
+		"  url = {https://go.dev/talks/2012/splash.article},\n",
+	} {
+		if !strings.Contains(bib, expected) {
+			t.Errorf("expected %q in the BibTeX, got\n%s", expected, bib)
+		}
+	}
+
+	// doi and url are written verbatim
+	verbatim := []*PublicationCard{{
+		CardBase: CardBase{Name: "Escaping_100%", Link: "https://example.com/paper?id=a_b%20c&v=1"},
+		Authors:  []string{"Rob Pike"}, Year: 2012, DOI: "10.1000/abc_def%2F",
+	}}
+	if data, err = encodeBibTeX(verbatim, []string{"pike2012escaping"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "@article{pike2012escaping,\n" +
+		"  title = {Escaping\\_100\\%},\n" +
+		"  author = {Rob Pike},\n" +
+		"  year = {2012},\n" +
+		"  doi = {10.1000/abc_def%2F},\n" +
+		"  url = {https://example.com/paper?id=a_b%20c&v=1},\n" +
+		"}\n"
+	if string(data) != expected {
+		t.Errorf("expected the BibTeX\n%s\ngot\n%s", expected, data)
+	}
+	verbatim[0].DOI = "10.1000/abc}"
+	if _, err := encodeBibTeX(verbatim, []string{"pike2012escaping"}); err == nil {
+		t.Error("expected unbalanced braces in the doi to fail")
+	}
 
+	data, err = encodeCSLJSON(publications, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []struct {
+		ID     string    `json:"id"`
+		Type   string    `json:"type"`
+		Author []cslName `json:"author"`
+		Issued struct {
+			DateParts [][]int `json:"date-parts"`
+		} `json:"issued"`
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 || items[0].Type != "book" || items[0].Author[1] != (cslName{Family: "Kernighan", Given: "Brian"}) ||
+		items[3].Author[0] != (cslName{Literal: "Renée"}) || items[1].Issued.DateParts[0][0] != 2012 {
+		t.Errorf("unexpected CSL-JSON %s", data)
+	}
 }
@@ -0,0 +1,223 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"os"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+// genericCardTpl is the card template of custom content types which do not
+// define their own
+const genericCardTpl = "card.html"
+
+// reservedNames are the routes and page templates of the server, custom
+// content types with these names would shadow them
+var reservedNames = map[string]bool{
+	"index":   true,
+	"about":   true,
+	"contact": true,
+	"tags":    true,
+	"mail":    true,
+	"static":  true,
+	"metrics": true,
+	"healthz": true,
+	"readyz":  true,
+	"success": true,
+	"fail":    true,
+	"base":    true,
+	"content": true,
+	"detail":  true,
+	"status":  true,
+}
+
+// CustomType declares a card content type in config.yml, so new sections
+// can be added without changing any code
+type CustomType struct {
+	// Name of the content type, used in the url and the content list
+	Name string `yaml:"name" json:"name" validate:"required"`
+	// Title of the page (default: name)
+	Title string `yaml:"title" json:"title,omitempty"`
+	// File the entries are loaded from (default: <name>.yml)
+	File string `yaml:"file" json:"file,omitempty"`
+	// Template rendering a single card, relative to the content templates
+	// dir or absolute (default: card.html)
+	Template string `yaml:"template" json:"template,omitempty"`
+	// Pager is the position in the previous/next links (default: not linked)
+	Pager int `yaml:"pager" json:"pager,omitempty"`
+}
+
+// contentType returns the registry entry of the custom type
+func (c *CustomType) contentType() *Type {
+	tpl := c.Template
+	if tpl == "" {
+		tpl = genericCardTpl
+	}
+	return &Type{
+		Name:         c.Name,
+		File:         c.File,
+		Title:        c.Title,
+		CardTemplate: tpl,
+		Pager:        c.Pager,
+		New:          func() ContentConfig { return &GenericConfig{} },
+	}
+}
+
+// checkName checks that the name is valid and not reserved by the server
+func (c *CustomType) checkName(t *Type) error {
+	if err := t.setDefaults(); err != nil {
+		return err
+	}
+	if reservedNames[t.Name] {
+		return fmt.Errorf("content type name '%s' is reserved", t.Name)
+	}
+	return nil
+}
+
+// Validate checks the name and that the card template exists
+func (c *CustomType) Validate() error {
+	t := c.contentType()
+	if err := c.checkName(t); err != nil {
+		return &utils.KeyError{Key: "name", Err: err}
+	}
+	if _, err := os.Stat(cardTemplatePath(t)); err != nil {
+		return &utils.KeyError{Key: "template", Err: fmt.Errorf("card template not found: %w", err)}
+	}
+	return nil
+}
+
+var (
+	// customTypes contains the custom content types currently registered
+	// (guarded by registryMu)
+	customTypes []*CustomType
+	// shadowed contains the built-in types replaced by custom types of the
+	// same name (see overridableTypes, guarded by registryMu)
+	shadowed = make(map[string]*Type)
+)
+
+// SetCustomTypes replaces the custom content types registered before with
+// custom, nothing is changed if one of them is invalid or its name is taken
+// (custom types replacing an overridable built-in one are accepted with a warning)
+func SetCustomTypes(custom []*CustomType) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	next := maps.Clone(registry)
+	for _, c := range customTypes {
+		if builtin, ok := shadowed[c.Name]; ok {
+			next[c.Name] = builtin
+		} else {
+			delete(next, c.Name)
+		}
+	}
+	nextShadowed := make(map[string]*Type)
+	for idx, c := range custom {
+		t := c.contentType()
+		key := fmt.Sprintf("customcontent.%d.name", idx)
+		if err := c.checkName(t); err != nil {
+			return &utils.KeyError{Key: key, Err: err}
+		}
+		if existing, exists := next[t.Name]; exists {
+			if !overridableTypes[t.Name] || overridden[t.Name] || nextShadowed[t.Name] != nil {
+				return &utils.KeyError{Key: key, Err: fmt.Errorf("content type %s is already registered", t.Name)}
+			}
+			warnOverride(t.Name)
+			nextShadowed[t.Name] = existing
+		}
+		next[t.Name] = t
+	}
+	registry = next
+	customTypes = custom
+	shadowed = nextShadowed
+	return nil
+}
+
+// CustomTypes returns the custom content types currently registered
+func CustomTypes() []*CustomType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return customTypes
+}
+
+// GenericConfig is the config of custom content types
+type GenericConfig struct {
+	TypeInfo `yaml:"-" json:"-"`
+	Entries  []*GenericCard `yaml:"entries" json:"entries,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ ContentConfig = &GenericConfig{}
+var _ CardContentConfig = &GenericConfig{}
+
+func (gc *GenericConfig) Elements() []Card {
+	return castToCard(gc.Entries)
+}
+
+func (gc *GenericConfig) setElements(cards []Card) {
+	gc.Entries = castFromCard[*GenericCard](cards)
+}
+
+// Validate checks the slugs of the cards
+func (gc *GenericConfig) Validate() error {
+	return checkSlugs("entries", gc.Elements())
+}
+
+func (gc *GenericConfig) Render() (*template.HTML, error) {
+	return renderCards(gc, gc.Type())
+}
+
+// GenericCard is a card of a custom content type, all keys besides the ones
+// of CardBase and CardDateRange are available in Fields
+type GenericCard struct {
+	CardBase      `yaml:",inline"`
+	CardDateRange `yaml:",inline"`
+	// Fields contains the additional keys, e.g. {{ .Fields.venue }} in the
+	// card template
+	Fields map[string]interface{} `yaml:",inline" json:"fields,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ Card = &GenericCard{}
+
+// HasDates returns true if the card has a date range
+func (g *GenericCard) HasDates() bool {
+	return !g.From.IsZero()
+}
+
+// Validate checks the date range, which is optional for custom content types
+func (g *GenericCard) Validate() error {
+	if !g.HasDates() && g.To == nil {
+		return nil
+	}
+	return g.CardDateRange.Validate()
+}
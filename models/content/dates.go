@@ -0,0 +1,495 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+// DatePrecision is the precision a date was written with in the yaml file
+type DatePrecision int
+
+const (
+	// PrecisionDay dates are written as 2021-03-14 (or as timestamp)
+	PrecisionDay DatePrecision = iota
+	// PrecisionMonth dates are written as 2021-03
+	PrecisionMonth
+	// PrecisionYear dates are written as 2021
+	PrecisionYear
+)
+
+// Date is a date of a date range, which may be written with the precision of
+// a day (2021-03-14), a month (2021-03) or a year (2021)
+type Date struct {
+	time.Time
+	// Precision is the precision the date was written with
+	Precision DatePrecision
+}
+
+// dateLayouts are the layouts of the dates by precision
+var dateLayouts = map[DatePrecision]string{
+	PrecisionDay:   time.DateOnly,
+	PrecisionMonth: "2006-01",
+	PrecisionYear:  "2006",
+}
+
+// parseDate parses dates with the precision of a day, a month or a year,
+// e.g. quoted ones which are not decoded as timestamps by yaml
+func parseDate(value string) (Date, bool) {
+	for _, precision := range []DatePrecision{PrecisionDay, PrecisionMonth, PrecisionYear} {
+		if t, err := time.Parse(dateLayouts[precision], value); err == nil {
+			return Date{Time: t, Precision: precision}, true
+		}
+	}
+	return Date{}, false
+}
+
+// UnmarshalYAML decodes dates and timestamps as well as dates with the
+// precision of a month or a year
+func (d *Date) UnmarshalYAML(node *yaml.Node) error {
+	var t time.Time
+	if err := node.Decode(&t); err == nil {
+		*d = Date{Time: t, Precision: PrecisionDay}
+		return nil
+	}
+	date, ok := parseDate(node.Value)
+	if !ok {
+		// the line makes the validate command report the position
+		return fmt.Errorf("line %d: cannot parse '%s' as date (expected e.g. 2021-03-14, 2021-03 or 2021)", node.Line, node.Value)
+	}
+	*d = date
+	return nil
+}
+
+// periodEnd returns the end of the day, month or year of the date (exclusive)
+func (d Date) periodEnd() time.Time {
+	switch d.Precision {
+	case PrecisionMonth:
+		return d.AddDate(0, 1, 0)
+	case PrecisionYear:
+		return d.AddDate(1, 0, 0)
+	}
+	return d.AddDate(0, 0, 1)
+}
+
+// locale contains the wording of the dates in a language
+type locale struct {
+	monthNames      [12]string
+	shortMonthNames [12]string
+	// year, years, month and months are the units of durations
+	year, years, month, months string
+	// lessThanMonth is the duration of ranges shorter than a month
+	lessThanMonth string
+	// yearAgo, yearsAgo, monthAgo and monthsAgo are the formats of relative
+	// dates, thisMonth is used for dates less than a month ago
+	yearAgo, yearsAgo, monthAgo, monthsAgo, thisMonth string
+	// tenure is the format of the total time spent at a company, the
+	// arguments are the duration and the company
+	tenure string
+	// ongoing is the default end of ranges which have not ended yet
+	ongoing string
+}
+
+// locales contains the supported languages, the first is the default
+var locales = []struct {
+	tag language.Tag
+	*locale
+}{
+	{language.English, &locale{
+		monthNames:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonthNames: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		year:            "yr",
+		years:           "yrs",
+		month:           "mo",
+		months:          "mos",
+		lessThanMonth:   "less than a month",
+		yearAgo:         "%d year ago",
+		yearsAgo:        "%d years ago",
+		monthAgo:        "%d month ago",
+		monthsAgo:       "%d months ago",
+		thisMonth:       "this month",
+		tenure:          "%s at %s in total",
+		ongoing:         "now",
+	}},
+	{language.German, &locale{
+		monthNames:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonthNames: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		year:            "Jahr",
+		years:           "Jahre",
+		month:           "Monat",
+		months:          "Monate",
+		lessThanMonth:   "weniger als ein Monat",
+		yearAgo:         "vor %d Jahr",
+		yearsAgo:        "vor %d Jahren",
+		monthAgo:        "vor %d Monat",
+		monthsAgo:       "vor %d Monaten",
+		thisMonth:       "diesen Monat",
+		tenure:          "insgesamt %s bei %s",
+		ongoing:         "heute",
+	}},
+	{language.French, &locale{
+		monthNames:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonthNames: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		year:            "an",
+		years:           "ans",
+		month:           "mois",
+		months:          "mois",
+		lessThanMonth:   "moins d'un mois",
+		yearAgo:         "il y a %d an",
+		yearsAgo:        "il y a %d ans",
+		monthAgo:        "il y a %d mois",
+		monthsAgo:       "il y a %d mois",
+		thisMonth:       "ce mois-ci",
+		tenure:          "%s chez %s au total",
+		ongoing:         "aujourd'hui",
+	}},
+	{language.Italian, &locale{
+		monthNames:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonthNames: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		year:            "anno",
+		years:           "anni",
+		month:           "mese",
+		months:          "mesi",
+		lessThanMonth:   "meno di un mese",
+		yearAgo:         "%d anno fa",
+		yearsAgo:        "%d anni fa",
+		monthAgo:        "%d mese fa",
+		monthsAgo:       "%d mesi fa",
+		thisMonth:       "questo mese",
+		tenure:          "%s presso %s in totale",
+		ongoing:         "oggi",
+	}},
+	{language.Spanish, &locale{
+		monthNames:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonthNames: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		year:            "año",
+		years:           "años",
+		month:           "mes",
+		months:          "meses",
+		lessThanMonth:   "menos de un mes",
+		yearAgo:         "hace %d año",
+		yearsAgo:        "hace %d años",
+		monthAgo:        "hace %d mes",
+		monthsAgo:       "hace %d meses",
+		thisMonth:       "este mes",
+		tenure:          "%s en %s en total",
+		ongoing:         "hoy",
+	}},
+}
+
+// localeMatcher matches the configured locale to the supported languages
+var localeMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(locales))
+	for idx, l := range locales {
+		tags[idx] = l.tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+// lookupLocale returns the supported language closest to tag (e.g. de for de-CH)
+func lookupLocale(tag string) (*locale, error) {
+	if tag == "" {
+		return locales[0].locale, nil
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale '%s': %w", tag, err)
+	}
+	_, idx, confidence := localeMatcher.Match(parsed)
+	if confidence == language.No {
+		supported := make([]string, len(locales))
+		for i, l := range locales {
+			supported[i] = l.tag.String()
+		}
+		return nil, fmt.Errorf("unsupported locale '%s' (supported are %s)", tag, strings.Join(supported, ", "))
+	}
+	return locales[idx].locale, nil
+}
+
+// DateConfig configures how the dates of the cards are presented
+type DateConfig struct {
+	// Locale is the language of the month names, durations and relative
+	// dates: en (default), de, fr, it or es (regions like de-CH are matched)
+	Locale string `yaml:"locale" json:"locale,omitempty"`
+	// Ongoing is the end of ranges which have not ended yet, the default
+	// depends on the locale ('now' in english)
+	Ongoing string `yaml:"ongoing" json:"ongoing,omitempty"`
+	// Format is the default layout of dates with a day (default: 2006-01-02)
+	Format string `yaml:"format" json:"format,omitempty"`
+	// MonthFormat is the default layout of dates with a month but no day
+	// (default: 2006-01)
+	MonthFormat string `yaml:"monthformat" json:"monthformat,omitempty"`
+}
+
+// Validate checks that the locale is supported
+func (c *DateConfig) Validate() error {
+	if _, err := lookupLocale(c.Locale); err != nil {
+		return &utils.KeyError{Key: "locale", Err: err}
+	}
+	return nil
+}
+
+// dateStyle is the active date configuration with the resolved locale
+type dateStyle struct {
+	config DateConfig
+	locale *locale
+}
+
+var (
+	dateStyleMu sync.RWMutex
+	// currentDateStyle is the configured date style (see SetDateConfig)
+	currentDateStyle = &dateStyle{locale: locales[0].locale}
+)
+
+// SetDateConfig replaces the configuration of the dates, nil restores the defaults
+func SetDateConfig(cfg *DateConfig) error {
+	if cfg == nil {
+		cfg = &DateConfig{}
+	}
+	loc, err := lookupLocale(cfg.Locale)
+	if err != nil {
+		return err
+	}
+	dateStyleMu.Lock()
+	defer dateStyleMu.Unlock()
+	currentDateStyle = &dateStyle{config: *cfg, locale: loc}
+	return nil
+}
+
+// CurrentDateConfig returns the current configuration of the dates
+func CurrentDateConfig() *DateConfig {
+	dateStyleMu.RLock()
+	defer dateStyleMu.RUnlock()
+	cfg := currentDateStyle.config
+	return &cfg
+}
+
+func getDateStyle() *dateStyle {
+	dateStyleMu.RLock()
+	defer dateStyleMu.RUnlock()
+	return currentDateStyle
+}
+
+// layout returns the explicit layout or the default one for the precision of date
+func (s *dateStyle) layout(explicit string, date Date) string {
+	switch {
+	case explicit != "":
+		return explicit
+	case date.Precision == PrecisionYear:
+		return dateLayouts[PrecisionYear]
+	case date.Precision == PrecisionMonth && s.config.MonthFormat != "":
+		return s.config.MonthFormat
+	case date.Precision == PrecisionMonth:
+		return dateLayouts[PrecisionMonth]
+	case s.config.Format != "":
+		return s.config.Format
+	}
+	return dateLayouts[PrecisionDay]
+}
+
+// ongoing returns the configured end of ranges which have not ended yet
+func (s *dateStyle) ongoing() string {
+	if s.config.Ongoing != "" {
+		return s.config.Ongoing
+	}
+	return s.locale.ongoing
+}
+
+// The month names of the layout are replaced with these placeholders, which
+// time.Format keeps as they are
+const (
+	monthPlaceholder      = "\x00M\x00"
+	shortMonthPlaceholder = "\x00m\x00"
+)
+
+// format formats t with layout using the month names of the locale
+func (s *dateStyle) format(t time.Time, layout string) string {
+	layout = strings.ReplaceAll(layout, "January", monthPlaceholder)
+	layout = strings.ReplaceAll(layout, "Jan", shortMonthPlaceholder)
+	formatted := t.Format(layout)
+	formatted = strings.ReplaceAll(formatted, monthPlaceholder, s.locale.monthNames[t.Month()-1])
+	return strings.ReplaceAll(formatted, shortMonthPlaceholder, s.locale.shortMonthNames[t.Month()-1])
+}
+
+// monthsBetween returns the number of full months from start to end
+func monthsBetween(start time.Time, end time.Time) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	return max(months, 0)
+}
+
+// duration formats a number of months as e.g. '2 yrs 4 mos'
+func (s *dateStyle) duration(months int) string {
+	if months == 0 {
+		return s.locale.lessThanMonth
+	}
+	var parts []string
+	if years := months / 12; years > 0 {
+		unit := s.locale.years
+		if years == 1 {
+			unit = s.locale.year
+		}
+		parts = append(parts, strconv.Itoa(years)+" "+unit)
+	}
+	if months %= 12; months > 0 {
+		unit := s.locale.months
+		if months == 1 {
+			unit = s.locale.month
+		}
+		parts = append(parts, strconv.Itoa(months)+" "+unit)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ago formats the time passed from t to now as e.g. '3 years ago'
+func (s *dateStyle) ago(t time.Time, now time.Time) string {
+	months := monthsBetween(t, now)
+	switch {
+	case months >= 24:
+		return fmt.Sprintf(s.locale.yearsAgo, months/12)
+	case months >= 12:
+		return fmt.Sprintf(s.locale.yearAgo, 1)
+	case months > 1:
+		return fmt.Sprintf(s.locale.monthsAgo, months)
+	case months == 1:
+		return fmt.Sprintf(s.locale.monthAgo, 1)
+	}
+	return s.locale.thisMonth
+}
+
+// tenure formats the total time spent at company as e.g. '4 yrs at Gopher Inc. in total'
+func (s *dateStyle) tenure(duration string, company string) string {
+	return fmt.Sprintf(s.locale.tenure, duration, company)
+}
+
+// end returns the end of the range, ok is false for ranges which have not
+// ended yet (no end or a text like 'now')
+func (d *CardDateRange) end() (end Date, ok bool) {
+	switch to := d.To.(type) {
+	case time.Time:
+		return Date{Time: to, Precision: PrecisionDay}, true
+	case string:
+		return parseDate(to)
+	case int:
+		// years like 'to: 2021' are decoded as numbers
+		return parseDate(strconv.Itoa(to))
+	}
+	return Date{}, false
+}
+
+// span returns the start and the (exclusive) end of the range, the end of
+// ranges which have not ended yet is now
+func (d *CardDateRange) span(now time.Time) (time.Time, time.Time) {
+	if end, ok := d.end(); ok {
+		return d.From.Time, end.periodEnd()
+	}
+	return d.From.Time, now
+}
+
+// Ongoing returns true if the range has not ended yet
+func (d *CardDateRange) Ongoing() bool {
+	_, ended := d.end()
+	return !ended
+}
+
+// Duration returns the length of the range, e.g. '2 yrs 4 mos', the end of
+// ranges which have not ended yet is today
+func (d *CardDateRange) Duration() string {
+	start, end := d.span(time.Now())
+	return getDateStyle().duration(monthsBetween(start, end))
+}
+
+// FromAgo returns the time passed since the start of the range, e.g. '3 years ago'
+func (d *CardDateRange) FromAgo() string {
+	return getDateStyle().ago(d.From.Time, time.Now())
+}
+
+// ToAgo returns the time passed since the end of the range, e.g. '1 year ago',
+// or the wording of ranges which have not ended yet
+func (d *CardDateRange) ToAgo() string {
+	style := getDateStyle()
+	if end, ok := d.end(); ok {
+		return style.ago(end.Time, time.Now())
+	}
+	if str, ok := d.To.(string); ok {
+		return str
+	}
+	return style.ongoing()
+}
+
+// totalMonths returns the number of months covered by the ranges, months
+// in which several of them overlap are counted once
+func totalMonths(ranges []*CardDateRange, now time.Time) int {
+	type span struct{ start, end time.Time }
+	spans := make([]span, 0, len(ranges))
+	for _, d := range ranges {
+		if d.From.IsZero() {
+			continue
+		}
+		start, end := d.span(now)
+		spans = append(spans, span{start, end})
+	}
+	slices.SortFunc(spans, func(a, b span) int { return a.start.Compare(b.start) })
+
+	var total int
+	for idx := 0; idx < len(spans); {
+		merged := spans[idx]
+		for idx++; idx < len(spans) && !spans[idx].start.After(merged.end); idx++ {
+			if spans[idx].end.After(merged.end) {
+				merged.end = spans[idx].end
+			}
+		}
+		total += monthsBetween(merged.start, merged.end)
+	}
+	return total
+}
+
+// nextDateChange returns the start of the day after now if any of the cards
+// has a date range, the durations and relative dates change with the day
+func nextDateChange(cards []Card, now time.Time) time.Time {
+	for _, card := range cards {
+		if dated, ok := card.(datedCard); ok && !dated.dateRange().From.IsZero() {
+			year, month, day := now.Date()
+			return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	apputils "github.com/bossm8/portfoli.go/utils"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+// ErrCardNotFound is returned for slugs without a detail page
+var ErrCardNotFound = errors.New("card not found")
+
+// slugRegex matches valid slugs, they are used in paths and file names
+var slugRegex = nameRegex
+
+// CardDetailData the data which is passed to the detail template of a card
+type CardDetailData struct {
+	// Type is the content type of the card and Title its title
+	Type  string
+	Title string
+	// Card is the card to show, with its html fields processed
+	Card Card
+	// Prev and Next are the neighbouring cards in the list which have a detail
+	// page, nil at the start/end of the list
+	Prev Card
+	Next Card
+}
+
+// slugify derives a slug from name, e.g. 'Renée's Portfoli.go' becomes 'renees-portfoli-go'
+func slugify(name string) string {
+	// strip the accents, é is decomposed into e and a combining mark
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn))), name)
+	if err != nil {
+		stripped = name
+	}
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(stripped) {
+		switch {
+		case r == '\'' || r == '’':
+			continue
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// checkSlugs checks the slugs of the cards, key is the yaml key of the list
+// the cards are loaded from. Explicit slugs must be valid and the ones of
+// cards with a detail page unique.
+func checkSlugs(key string, cards []Card) error {
+	seen := make(map[string]int, len(cards))
+	for idx, card := range cards {
+		base := card.Base()
+		if base.Slug != "" && !slugRegex.MatchString(base.Slug) {
+			return &utils.KeyError{
+				Key: fmt.Sprintf("%s.%d.slug", key, idx),
+				Err: fmt.Errorf("invalid slug '%s', it may only contain lower case letters, digits and dashes", base.Slug),
+			}
+		}
+		if base.Body == "" {
+			continue
+		}
+		slug := base.GetSlug()
+		if slug == "" {
+			return &utils.KeyError{
+				Key: fmt.Sprintf("%s.%d", key, idx),
+				Err: errors.New("no slug can be derived from the name, please set one with the key slug"),
+			}
+		}
+		if prev, ok := seen[slug]; ok {
+			return &utils.KeyError{
+				Key: fmt.Sprintf("%s.%d.slug", key, idx),
+				Err: fmt.Errorf("duplicate slug '%s' (also used by %s.%d)", slug, key, prev),
+			}
+		}
+		seen[slug] = idx
+	}
+	return nil
+}
+
+// prepareCards sets the content type of the cards of t and the detail paths
+// of the ones which have a body
+func prepareCards(cards []Card, t *Type) {
+	for _, card := range cards {
+		base := card.Base()
+		base.contentType = t.Name
+		if base.Body != "" {
+			base.detailPath = "/" + t.Name + "/" + base.GetSlug()
+		}
+	}
+}
+
+// renderDetails returns the data of the detail pages of all cards of obj
+// which have one and are published at now, in the order they are listed (see
+// sortCards). Only listed cards are linked as previous/next, so hidden cards
+// are reachable by their path only.
+func renderDetails(obj CardContentConfig, t *Type, now time.Time) ([]*CardDetailData, error) {
+	var cards []Card
+	for _, card := range sortCards(publishedCards(obj.Elements(), now), t) {
+		if card.Base().DetailPath() != "" {
+			cards = append(cards, card)
+		}
+	}
+	listed := listedCards(cards, now)
+
+	details := make([]*CardDetailData, len(cards))
+	for idx, card := range cards {
+		// the list was rendered already, so the html fields can be
+		// processed in place (see renderContent)
+		base := card.Base()
+		for _, html := range []*template.HTML{&base.Description, &base.Body} {
+			processed, err := apputils.ProcessHTMLContent(html)
+			if err != nil {
+				return nil, fmt.Errorf("card %s: %w", base.GetSlug(), err)
+			}
+			*html = *processed
+		}
+		details[idx] = &CardDetailData{Type: t.Name, Title: t.Title, Card: card}
+		if pos := slices.Index(listed, card); pos >= 0 {
+			if pos > 0 {
+				details[idx].Prev = listed[pos-1]
+			}
+			if pos < len(listed)-1 {
+				details[idx].Next = listed[pos+1]
+			}
+		}
+	}
+	return details, nil
+}
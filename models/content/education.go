@@ -30,8 +30,18 @@ package content
 
 import "html/template"
 
+func init() {
+	MustRegister(&Type{
+		Name:         "education",
+		CardTemplate: "education.html",
+		Pager:        2,
+		New:          func() ContentConfig { return &EducationConfig{} },
+	})
+}
+
 type EducationConfig struct {
-	Educations []*EducationCard `yaml:"educations"`
+	TypeInfo   `yaml:"-" json:"-"`
+	Educations []*EducationCard `yaml:"educations" json:"educations,omitempty"`
 }
 
 // Make sure the interface is implemented
@@ -42,36 +52,29 @@ func (ec *EducationConfig) Elements() []Card {
 	return castToCard(ec.Educations)
 }
 
-func (ec *EducationConfig) ConfigName() string {
-	return ec.ContentType() + ".yml"
-}
-
-func (ec *EducationConfig) ContentType() string {
-	return ContentTypes[typeEducation]
+func (ec *EducationConfig) setElements(cards []Card) {
+	ec.Educations = castFromCard[*EducationCard](cards)
 }
 
-func (ec *EducationConfig) Title() string {
-	return ec.ContentType()
+// Validate checks the slugs of the cards
+func (ec *EducationConfig) Validate() error {
+	return checkSlugs("educations", ec.Elements())
 }
 
 func (ec *EducationConfig) Render() (*template.HTML, error) {
-	return renderCards(ec, ec.ContentType())
+	return renderCards(ec, ec.Type())
 }
 
 type EducationCard struct {
 	CardBase       `yaml:",inline"`
-	School         string `yaml:"school"`
-	Specialization string `yaml:"specialization"`
+	School         string `yaml:"school" json:"school,omitempty"`
+	Specialization string `yaml:"specialization" json:"specialization,omitempty"`
 	CardDateRange  `yaml:",inline"`
 }
 
 // Make sure the interface is implemented
 var _ Card = &EducationCard{}
 
-func (e *EducationCard) CardTemplateName() string {
-	return "education.html"
-}
-
 // ImageRef shadows CardBase's promoted method: education entries no longer
 // render an image (see education.html), so there's nothing to cache. The
 // `image` yaml field is kept on CardBase only so existing configs which
@@ -28,49 +28,84 @@
 
 package content
 
-import "html/template"
+import (
+	"html/template"
+	"time"
+)
+
+func init() {
+	MustRegister(&Type{
+		Name:         "experience",
+		CardTemplate: "experience.html",
+		Pager:        1,
+		New:          func() ContentConfig { return &ExperienceConfig{} },
+	})
+}
 
 type ExperienceConfig struct {
-	Experiences []*ExperienceCard `yaml:"experiences"`
+	TypeInfo    `yaml:"-" json:"-"`
+	Experiences []*ExperienceCard `yaml:"experiences" json:"experiences,omitempty"`
 }
 
 // Make sure the interface is implemented
 var _ ContentConfig = &ExperienceConfig{}
 var _ CardContentConfig = &ExperienceConfig{}
+var _ skillReferrer = &ExperienceConfig{}
 
 func (ec *ExperienceConfig) Elements() []Card {
 	return castToCard(ec.Experiences)
 }
 
-func (ec *ExperienceConfig) ConfigName() string {
-	return ec.ContentType() + ".yml"
+func (ec *ExperienceConfig) setElements(cards []Card) {
+	ec.Experiences = castFromCard[*ExperienceCard](cards)
+}
+
+// Validate checks the slugs of the cards
+func (ec *ExperienceConfig) Validate() error {
+	return checkSlugs(ec.cardsKey(), ec.Elements())
 }
 
-func (ec *ExperienceConfig) ContentType() string {
-	return ContentTypes[typeExperience]
+// prepare derives the date range of the entries with roles from them
+func (ec *ExperienceConfig) prepare() {
+	for _, e := range ec.Experiences {
+		e.setRangeFromRoles()
+	}
 }
 
-func (ec *ExperienceConfig) Title() string {
-	return ec.ContentType()
+// cardsKey returns the yaml key of the cards
+func (ec *ExperienceConfig) cardsKey() string {
+	return "experiences"
 }
 
 func (ec *ExperienceConfig) Render() (*template.HTML, error) {
-	return renderCards(ec, ec.ContentType())
+	setCompanyTenures(ec.Experiences, time.Now())
+	return renderCards(ec, ec.Type())
 }
 
 type ExperienceCard struct {
 	CardBase      `yaml:",inline"`
-	Company       string `yaml:"company"`
+	Company       string `yaml:"company" json:"company,omitempty"`
+	CardDateRange `yaml:",inline"`
+	SkillRefs     `yaml:",inline"`
+	// Roles held at the company, e.g. after promotions, the date range of
+	// the entry defaults to the one spanning all of them
+	Roles []*ExperienceRole `yaml:"roles" json:"roles,omitempty"`
+	// companyTenure is the total tenure at the company (see setCompanyTenures)
+	companyTenure string
+}
+
+// ExperienceRole is a role of an experience entry
+type ExperienceRole struct {
+	// Name of the role
+	Name string `yaml:"name" json:"name,omitempty" validate:"required"`
+	// Description of the role in HTML
+	Description   template.HTML `yaml:"description" json:"description,omitempty"`
 	CardDateRange `yaml:",inline"`
 }
 
 // Make sure the interface is implemented
 var _ Card = &ExperienceCard{}
 
-func (e *ExperienceCard) CardTemplateName() string {
-	return "experience.html"
-}
-
 // ImageRef shadows CardBase's promoted method: experience entries no longer
 // render an image (see experience.html), so there's nothing to cache. The
 // `image` yaml field is kept on CardBase only so existing configs which
@@ -78,3 +113,98 @@ func (e *ExperienceCard) CardTemplateName() string {
 func (e *ExperienceCard) ImageRef() *string {
 	return nil
 }
+
+// Validate checks the date range, which may be left out if the entry has
+// roles (it is derived from them then)
+func (e *ExperienceCard) Validate() error {
+	if len(e.Roles) > 0 && e.From.IsZero() && e.To == nil {
+		return nil
+	}
+	return e.CardDateRange.Validate()
+}
+
+// setRangeFromRoles sets the date range of entries with roles but without an
+// explicit range to the one from the first start to the last end of the roles
+func (e *ExperienceCard) setRangeFromRoles() {
+	if len(e.Roles) == 0 || !e.From.IsZero() || e.To != nil {
+		return
+	}
+	var (
+		last    Date
+		lastTo  interface{}
+		ongoing bool
+	)
+	for _, role := range e.Roles {
+		if !role.From.IsZero() && (e.From.IsZero() || role.From.Before(e.From.Time)) {
+			e.From = role.From
+		}
+		end, ok := role.end()
+		if !ok {
+			// the entry is ongoing as well, with the wording of the first
+			// ongoing role (e.g. 'present')
+			if !ongoing {
+				lastTo, ongoing = role.To, true
+			}
+			continue
+		}
+		if !ongoing && (lastTo == nil || end.periodEnd().After(last.periodEnd())) {
+			last, lastTo = end, role.To
+		}
+	}
+	e.To = lastTo
+}
+
+// CompanyTenure returns the total time spent at the company of the card,
+// e.g. '4 yrs 2 mos', empty if it is the only card of the company and has no
+// roles
+func (e *ExperienceCard) CompanyTenure() string {
+	return e.companyTenure
+}
+
+// CompanyTenureText returns the company tenure in a sentence in the
+// configured locale, e.g. '4 yrs 2 mos at Gopher Inc. in total', empty if
+// there is no tenure
+func (e *ExperienceCard) CompanyTenureText() string {
+	if e.companyTenure == "" {
+		return ""
+	}
+	return getDateStyle().tenure(e.companyTenure, e.Company)
+}
+
+// ranges returns the date ranges of the roles or the one of the entry if it has none
+func (e *ExperienceCard) ranges() []*CardDateRange {
+	if len(e.Roles) == 0 {
+		return []*CardDateRange{&e.CardDateRange}
+	}
+	ranges := make([]*CardDateRange, len(e.Roles))
+	for idx, role := range e.Roles {
+		ranges[idx] = &role.CardDateRange
+	}
+	return ranges
+}
+
+// setCompanyTenures sets the tenure of the companies with several published
+// cards or roles, overlapping roles are counted once
+func setCompanyTenures(experiences []*ExperienceCard, now time.Time) {
+	byCompany := make(map[string][]*ExperienceCard)
+	for _, e := range experiences {
+		e.companyTenure = ""
+		if e.Company != "" && published(e, now) {
+			byCompany[e.Company] = append(byCompany[e.Company], e)
+		}
+	}
+	style := getDateStyle()
+	for _, cards := range byCompany {
+		var ranges []*CardDateRange
+		for _, e := range cards {
+			ranges = append(ranges, e.ranges()...)
+		}
+		if len(ranges) < 2 {
+			continue
+		}
+		tenure := style.duration(totalMonths(ranges, now))
+		for _, e := range cards {
+			e.companyTenure = tenure
+		}
+	}
+}
@@ -30,41 +30,51 @@ package content
 
 import "html/template"
 
+func init() {
+	MustRegister(&Type{
+		Name:         "projects",
+		CardTemplate: "project.html",
+		Pager:        4,
+		New:          func() ContentConfig { return &ProjectConfig{} },
+	})
+}
+
 type ProjectConfig struct {
-	Projects []*ProjectCard `yaml:"projects"`
+	TypeInfo `yaml:"-" json:"-"`
+	Projects []*ProjectCard `yaml:"projects" json:"projects,omitempty"`
 }
 
 // Make sure the interface is implemented
 var _ ContentConfig = &ProjectConfig{}
 var _ CardContentConfig = &ProjectConfig{}
+var _ skillReferrer = &ProjectConfig{}
 
 func (pc *ProjectConfig) Elements() []Card {
 	return castToCard(pc.Projects)
 }
 
-func (pc *ProjectConfig) ConfigName() string {
-	return pc.ContentType() + ".yml"
+func (pc *ProjectConfig) setElements(cards []Card) {
+	pc.Projects = castFromCard[*ProjectCard](cards)
 }
 
-func (pc *ProjectConfig) ContentType() string {
-	return ContentTypes[typeProject]
+// Validate checks the slugs of the cards
+func (pc *ProjectConfig) Validate() error {
+	return checkSlugs(pc.cardsKey(), pc.Elements())
 }
 
-func (pc *ProjectConfig) Render() (*template.HTML, error) {
-	return renderCards(pc, pc.ContentType())
+// cardsKey returns the yaml key of the cards
+func (pc *ProjectConfig) cardsKey() string {
+	return "projects"
 }
 
-func (pc *ProjectConfig) Title() string {
-	return pc.ContentType()
+func (pc *ProjectConfig) Render() (*template.HTML, error) {
+	return renderCards(pc, pc.Type())
 }
 
 type ProjectCard struct {
-	CardBase `yaml:",inline"`
+	CardBase  `yaml:",inline"`
+	SkillRefs `yaml:",inline"`
 }
 
 // Make sure the interface is implemented
 var _ Card = &ProjectCard{}
-
-func (p *ProjectCard) CardTemplateName() string {
-	return "project.html"
-}
@@ -0,0 +1,172 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+func init() {
+	MustRegister(&Type{
+		Name:         "publications",
+		Title:        "Publications",
+		CardTemplate: "publication.html",
+		Pager:        6,
+		New:          func() ContentConfig { return &PublicationConfig{} },
+	})
+}
+
+type PublicationConfig struct {
+	TypeInfo     `yaml:"-" json:"-"`
+	Publications []*PublicationCard `yaml:"publications" json:"publications,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ ContentConfig = &PublicationConfig{}
+var _ CardContentConfig = &PublicationConfig{}
+var _ citer = &PublicationConfig{}
+
+func (pc *PublicationConfig) Elements() []Card {
+	return castToCard(pc.Publications)
+}
+
+func (pc *PublicationConfig) setElements(cards []Card) {
+	pc.Publications = castFromCard[*PublicationCard](cards)
+}
+
+// Validate checks the slugs of the cards
+func (pc *PublicationConfig) Validate() error {
+	return checkSlugs("publications", pc.Elements())
+}
+
+func (pc *PublicationConfig) Render() (*template.HTML, error) {
+	return renderCards(pc, pc.Type())
+}
+
+// publicationKind maps a type of publication to the types and keys of the
+// citation formats
+type publicationKind struct {
+	// bibType is the BibTeX entry type and bibVenue the key of the venue
+	bibType, bibVenue string
+	// cslType is the CSL type and cslVenue the key of the venue
+	cslType, cslVenue string
+}
+
+// publicationKinds are the supported types of publications
+var publicationKinds = map[string]publicationKind{
+	"article":       {"article", "journal", "article-journal", "container-title"},
+	"inproceedings": {"inproceedings", "booktitle", "paper-conference", "container-title"},
+	"book":          {"book", "publisher", "book", "publisher"},
+	"thesis":        {"phdthesis", "school", "thesis", "publisher"},
+	"report":        {"techreport", "institution", "report", "publisher"},
+	"misc":          {"misc", "howpublished", "document", "publisher"},
+}
+
+// PublicationCard is a paper, book, thesis etc.
+type PublicationCard struct {
+	CardBase `yaml:",inline"`
+	// Authors in the order of the publication, e.g. 'Renée French'
+	Authors []string `yaml:"authors" json:"authors,omitempty" validate:"required"`
+	// Venue is the journal, conference, publisher or school
+	Venue string `yaml:"venue" json:"venue,omitempty"`
+	// Year of the publication
+	Year int `yaml:"year" json:"year,omitempty" validate:"required"`
+	// Kind of the publication: article (default), inproceedings, book,
+	// thesis, report or misc
+	Kind string `yaml:"type" json:"type,omitempty"`
+	// DOI of the publication, e.g. 10.1000/182
+	DOI string `yaml:"doi" json:"doi,omitempty"`
+	// PDF is the link to the full text
+	PDF string `yaml:"pdf" json:"pdf,omitempty" validate:"url"`
+	// Key is the citation key, defaults to the last name of the first author,
+	// the year and the first word of the title (e.g. french2023gophers)
+	Key string `yaml:"key" json:"key,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ Card = &PublicationCard{}
+
+// Validate checks the type of the publication
+func (p *PublicationCard) Validate() error {
+	if p.Kind == "" {
+		return nil
+	}
+	if _, ok := publicationKinds[p.Kind]; !ok {
+		return &utils.KeyError{
+			Key: "type",
+			Err: fmt.Errorf("unknown type '%s' (expected %s)", p.Kind, strings.Join(slices.Sorted(maps.Keys(publicationKinds)), ", ")),
+		}
+	}
+	return nil
+}
+
+// kind returns the type of the publication
+func (p *PublicationCard) kind() publicationKind {
+	if kind, ok := publicationKinds[p.Kind]; ok {
+		return kind
+	}
+	return publicationKinds["article"]
+}
+
+// dateRange returns the year of the publication, so publications can be
+// sorted by date
+func (p *PublicationCard) dateRange() *CardDateRange {
+	if p.Year == 0 {
+		return &CardDateRange{}
+	}
+	year := Date{Time: time.Date(p.Year, time.January, 1, 0, 0, 0, 0, time.UTC), Precision: PrecisionYear}
+	return &CardDateRange{From: year, To: year.Time}
+}
+
+// AuthorList returns the authors separated with commas and 'and' before the
+// last one, e.g. 'Ken Thompson, Rob Pike and Robert Griesemer'
+func (p *PublicationCard) AuthorList() string {
+	switch len(p.Authors) {
+	case 0:
+		return ""
+	case 1:
+		return p.Authors[0]
+	}
+	return strings.Join(p.Authors[:len(p.Authors)-1], ", ") + " and " + p.Authors[len(p.Authors)-1]
+}
+
+// DOILink returns the link resolving the DOI, empty if there is none
+func (p *PublicationCard) DOILink() string {
+	if p.DOI == "" {
+		return ""
+	}
+	return "https://doi.org/" + p.DOI
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// previewDrafts includes unpublished cards (drafts, scheduled and expired
+// ones) in the rendered content
+var previewDrafts atomic.Bool
+
+// SetPreviewDrafts enables or disables rendering the cards which are not
+// published (yet), e.g. to preview them locally
+func SetPreviewDrafts(enabled bool) {
+	previewDrafts.Store(enabled)
+}
+
+// published returns true if card is shown at now: it is no draft and within
+// its publishing period (or drafts are previewed)
+func published(card Card, now time.Time) bool {
+	if previewDrafts.Load() {
+		return true
+	}
+	base := card.Base()
+	switch {
+	case base.Draft:
+		return false
+	case !base.PublishAfter.IsZero() && now.Before(base.PublishAfter):
+		return false
+	case !base.ExpireAfter.IsZero() && now.After(base.ExpireAfter):
+		return false
+	}
+	return true
+}
+
+// publishedCards returns the cards which are published at now, in their order
+func publishedCards(cards []Card, now time.Time) []Card {
+	var res []Card
+	for _, card := range cards {
+		if published(card, now) {
+			res = append(res, card)
+		}
+	}
+	return res
+}
+
+// listedCards returns the cards which are published at now and not hidden,
+// in their order
+func listedCards(cards []Card, now time.Time) []Card {
+	var res []Card
+	for _, card := range publishedCards(cards, now) {
+		if !card.Base().Hidden {
+			res = append(res, card)
+		}
+	}
+	return res
+}
+
+// nextPublishingChange returns the next point in time after now, at which a
+// card is published or expires, zero if there is none
+func nextPublishingChange(cards []Card, now time.Time) time.Time {
+	var next time.Time
+	for _, card := range cards {
+		base := card.Base()
+		for _, change := range []time.Time{base.PublishAfter, base.ExpireAfter} {
+			if change.After(now) && (next.IsZero() || change.Before(next)) {
+				next = change
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Type describes a content type, every content type is registered with
+// Register (the built-in ones when the package is initialized)
+type Type struct {
+	// Name of the content type, it is used in the url and in the content
+	// list of the profile
+	Name string
+	// File is the yaml file the content is loaded from (in the config dir)
+	File string
+	// Title of the page
+	Title string
+	// CardTemplate is the html template rendering a single element of a card
+	// content type (relative to the content templates dir), empty for types
+	// which are not rendered as cards
+	CardTemplate string
+	// Pager is the position of the type in the previous/next links at the
+	// bottom of the pages (see GetPagerLinks), types with 0 are not linked
+	Pager int
+	// New returns a new (empty) config the yaml file is loaded into
+	New func() ContentConfig
+}
+
+var (
+	registryMu sync.RWMutex
+	// registry contains all registered content types by name
+	registry = make(map[string]*Type)
+
+	nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+	// overridden contains the names of the overridableTypes replaced by
+	// Register, they cannot be replaced again
+	overridden = make(map[string]bool)
+
+	enabledMu sync.RWMutex
+	// enabled contains the names of the content types listed in the profile
+	enabled []string
+)
+
+// overridableTypes are the built-in content types added after configs could
+// declare their own types, a type registered or declared in config.yml with
+// one of these names replaces the built-in one, so existing configs keep working
+var overridableTypes = map[string]bool{
+	"skills":       true,
+	"publications": true,
+	"talks":        true,
+}
+
+// warnOverride logs that the built-in type name is replaced, which is deprecated
+func warnOverride(name string) {
+	slog.Warn("Replacing the built-in content type is deprecated, rename your type or migrate to the built-in one",
+		"content", name)
+}
+
+// Register adds the content type t, the name must be unique and consist of
+// lowercase letters, digits and dashes only, as it is used in the url
+func Register(t *Type) error {
+	if err := t.setDefaults(); err != nil {
+		return err
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return register(t)
+}
+
+// setDefaults checks the type and sets the defaults of optional fields
+func (t *Type) setDefaults() error {
+	switch {
+	case !nameRegex.MatchString(t.Name):
+		return fmt.Errorf("invalid content type name '%s'", t.Name)
+	case t.New == nil:
+		return fmt.Errorf("content type %s: missing constructor", t.Name)
+	}
+	if t.File == "" {
+		t.File = t.Name + ".yml"
+	}
+	if t.Title == "" {
+		t.Title = t.Name
+	}
+	return nil
+}
+
+// register adds t to the registry, registryMu must be held
+func register(t *Type) error {
+	if _, exists := registry[t.Name]; exists {
+		if !overridableTypes[t.Name] || overridden[t.Name] {
+			return fmt.Errorf("content type %s is already registered", t.Name)
+		}
+		warnOverride(t.Name)
+		overridden[t.Name] = true
+	}
+	registry[t.Name] = t
+	return nil
+}
+
+// MustRegister is the same as Register, but panics on error
+func MustRegister(t *Type) {
+	if err := Register(t); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registered content type called name
+func Lookup(name string) (*Type, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// TypeNames returns the names of all registered content types (sorted)
+func TypeNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// types returns all registered content types sorted by name
+func types() []*Type {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	all := make([]*Type, 0, len(registry))
+	for _, t := range registry {
+		all = append(all, t)
+	}
+	slices.SortFunc(all, func(a, b *Type) int { return strings.Compare(a.Name, b.Name) })
+	return all
+}
+
+// SetEnabledTypes sets the content types listed in the profile, content
+// linking to cards of other types (e.g. the skills) only links to these
+func SetEnabledTypes(names []string) {
+	enabledMu.Lock()
+	defer enabledMu.Unlock()
+	enabled = slices.Clone(names)
+}
+
+// EnabledTypes returns the content types set with SetEnabledTypes
+func EnabledTypes() []string {
+	enabledMu.RLock()
+	defer enabledMu.RUnlock()
+	return slices.Clone(enabled)
+}
+
+// ErrUnknownType is returned for content types which are not registered
+var ErrUnknownType = errors.New("unknown content type")
+
+// New returns a new (empty) config for contentType, so loading it does not
+// interfere with other requests
+func New(contentType string) (ContentConfig, error) {
+	t, ok := Lookup(contentType)
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownType, contentType)
+	}
+	obj := t.New()
+	obj.SetType(t)
+	return obj, nil
+}
+
+// TypeInfo implements the methods of ContentConfig which are derived from
+// the registered content type, configs embed it with `yaml:"-" json:"-"`
+type TypeInfo struct {
+	contentType *Type
+}
+
+// SetType sets the content type the config belongs to (done by New)
+func (i *TypeInfo) SetType(t *Type) {
+	i.contentType = t
+}
+
+// Type returns the content type the config belongs to
+func (i *TypeInfo) Type() *Type {
+	return i.contentType
+}
+
+// ConfigName returns the name of the yaml file of the content type
+func (i *TypeInfo) ConfigName() string {
+	return i.contentType.File
+}
+
+// ContentType returns the name of the content type
+func (i *TypeInfo) ContentType() string {
+	return i.contentType.Name
+}
+
+// Title returns the title of the content type
+func (i *TypeInfo) Title() string {
+	return i.contentType.Title
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bossm8/portfoli.go/config"
+	apputils "github.com/bossm8/portfoli.go/utils"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+func init() {
+	MustRegister(&Type{
+		Name:  "skills",
+		Title: "Skills",
+		Pager: 5,
+		New:   func() ContentConfig { return &SkillsConfig{} },
+	})
+}
+
+// SkillLevels are the proficiency levels of the skills, from the lowest to
+// the highest
+var SkillLevels = []string{"beginner", "intermediate", "advanced", "expert"}
+
+// SkillsConfig contains the skills grouped by category, the cards of other
+// content types reference them by their id (see SkillRefs)
+type SkillsConfig struct {
+	TypeInfo   `yaml:"-" json:"-"`
+	Categories []*SkillCategory `yaml:"categories" json:"categories,omitempty"`
+	// nextChange is the next time a card referencing skills is published or
+	// expires (see setSkillUsages)
+	nextChange time.Time
+}
+
+// Make sure the interface is implemented
+var _ ContentConfig = &SkillsConfig{}
+
+// SkillCategory is a group of skills, e.g. languages or tools
+type SkillCategory struct {
+	Name   string   `yaml:"name" json:"name,omitempty" validate:"required"`
+	Skills []*Skill `yaml:"skills" json:"skills,omitempty"`
+}
+
+// Skill is a single skill
+type Skill struct {
+	// ID is used to reference the skill from cards and as anchor on the skills
+	// page, lowercase letters, digits and dashes only (e.g. 'go')
+	ID   string `yaml:"id" json:"id" validate:"required"`
+	Name string `yaml:"name" json:"name,omitempty" validate:"required"`
+	// Level is the proficiency, one of SkillLevels
+	Level string `yaml:"level" json:"level,omitempty"`
+	// Years of experience with the skill
+	Years int `yaml:"years" json:"years,omitempty"`
+	// Description of the skill in HTML
+	Description template.HTML `yaml:"description" json:"description,omitempty"`
+	// usages are the cards referencing the skill (see setSkillUsages)
+	usages []*SkillUsage
+}
+
+// SkillUsage is a card referencing a skill
+type SkillUsage struct {
+	// Type is the title of the content type of the card
+	Type string
+	// Name of the card
+	Name string
+	// Path is the detail page of the card or the page of its content type
+	Path string
+}
+
+// Validate checks the id, level and years of the skill
+func (s *Skill) Validate() error {
+	switch {
+	case !slugRegex.MatchString(s.ID):
+		return &utils.KeyError{
+			Key: "id",
+			Err: fmt.Errorf("invalid id '%s', it may only contain lower case letters, digits and dashes", s.ID),
+		}
+	case s.Level != "" && !slices.Contains(SkillLevels, s.Level):
+		return &utils.KeyError{
+			Key: "level",
+			Err: fmt.Errorf("unknown level '%s' (expected %s)", s.Level, strings.Join(SkillLevels, ", ")),
+		}
+	case s.Years < 0:
+		return &utils.KeyError{Key: "years", Err: errors.New("must not be negative")}
+	}
+	return nil
+}
+
+// Rank returns the position of the level in SkillLevels starting at 1, 0 if
+// the skill has no level
+func (s *Skill) Rank() int {
+	return slices.Index(SkillLevels, s.Level) + 1
+}
+
+// Ranks returns the numbers from 1 to the highest rank, e.g. to render a meter
+func (s *Skill) Ranks() []int {
+	ranks := make([]int, len(SkillLevels))
+	for idx := range ranks {
+		ranks[idx] = idx + 1
+	}
+	return ranks
+}
+
+// Experience returns the years of experience as duration, e.g. '5 yrs'
+func (s *Skill) Experience() string {
+	if s.Years == 0 {
+		return ""
+	}
+	return getDateStyle().duration(s.Years * 12)
+}
+
+// Usages returns the listed cards referencing the skill
+func (s *Skill) Usages() []*SkillUsage {
+	return s.usages
+}
+
+// Validate checks that the ids of the skills are unique
+func (sc *SkillsConfig) Validate() error {
+	seen := make(map[string]string)
+	for cIdx, category := range sc.Categories {
+		for sIdx, skill := range category.Skills {
+			key := fmt.Sprintf("categories.%d.skills.%d", cIdx, sIdx)
+			if prev, ok := seen[skill.ID]; ok {
+				return &utils.KeyError{
+					Key: key + ".id",
+					Err: fmt.Errorf("duplicate id '%s' (also used by %s)", skill.ID, prev),
+				}
+			}
+			seen[skill.ID] = key
+		}
+	}
+	return nil
+}
+
+// lookup returns the skill with id, nil if there is none
+func (sc *SkillsConfig) lookup(id string) *Skill {
+	for _, category := range sc.Categories {
+		for _, skill := range category.Skills {
+			if skill.ID == id {
+				return skill
+			}
+		}
+	}
+	return nil
+}
+
+func (sc *SkillsConfig) Render() (*template.HTML, error) {
+	if err := sc.setSkillUsages(time.Now(), EnabledTypes()); err != nil {
+		return nil, err
+	}
+	baseTpl := filepath.Join(config.ContentTemplatesPath(), sc.ContentType()+".html")
+	result, err := apputils.RenderTemplate(sc.ContentType(), sc, baseTpl)
+	if err != nil {
+		slog.Error("Failed to render template", "template", baseTpl)
+		return nil, err
+	}
+	html := template.HTML(result)
+	return &html, nil
+}
+
+// SkillRefs references skills of the skills content type by their id
+type SkillRefs struct {
+	// Skills used, e.g. [go, docker]
+	Skills []string `yaml:"skills" json:"skills,omitempty"`
+}
+
+// skillRefs returns the ids of the referenced skills
+func (s *SkillRefs) skillRefs() []string {
+	return s.Skills
+}
+
+// skilledCard is implemented by the cards referencing skills
+type skilledCard interface {
+	skillRefs() []string
+}
+
+// skillReferrer is implemented by the content configs whose cards may
+// reference skills, key is the yaml key of the list of cards
+type skillReferrer interface {
+	CardContentConfig
+	cardsKey() string
+}
+
+// setSkillUsages sets the usages of the skills to the cards listed at now of
+// the enabled content types referencing skills, the ones without a yaml file
+// are skipped
+func (sc *SkillsConfig) setSkillUsages(now time.Time, enabled []string) error {
+	sc.nextChange = time.Time{}
+	for idx, name := range enabled {
+		t, ok := Lookup(name)
+		if !ok || slices.Index(enabled, name) != idx {
+			continue
+		}
+		obj, err := New(name)
+		if err != nil {
+			return err
+		}
+		if _, ok := obj.(skillReferrer); !ok || !utils.YAMLFileExists(obj.ConfigName()) {
+			continue
+		}
+		if err := loadContentConfig(obj); err != nil {
+			return err
+		}
+		elements := obj.(CardContentConfig).Elements()
+		if next := nextPublishingChange(elements, now); !next.IsZero() &&
+			(sc.nextChange.IsZero() || next.Before(sc.nextChange)) {
+			sc.nextChange = next
+		}
+		for _, card := range sortCards(listedCards(elements, now), t) {
+			base := card.Base()
+			path := base.DetailPath()
+			if path == "" {
+				path = "/" + t.Name
+			}
+			for _, id := range card.(skilledCard).skillRefs() {
+				skill := sc.lookup(id)
+				if skill == nil {
+					slog.Warn("Unknown skill referenced", "content", t.Name, "card", base.Name, "skill", id)
+					continue
+				}
+				skill.usages = append(skill.usages, &SkillUsage{Type: t.Title, Name: base.Name, Path: path})
+			}
+		}
+	}
+	return nil
+}
+
+// nextContentChange returns the time the usages change, the rendered skills
+// are valid until then
+func (sc *SkillsConfig) nextContentChange() time.Time {
+	return sc.nextChange
+}
+
+// CheckSkillRefs returns the references of the cards of obj to skills which
+// do not exist in skills, as KeyErrors with the position of the reference
+func CheckSkillRefs(obj ContentConfig, skills *SkillsConfig) []error {
+	referrer, ok := obj.(skillReferrer)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for cIdx, card := range referrer.Elements() {
+		for rIdx, id := range card.(skilledCard).skillRefs() {
+			if skills.lookup(id) == nil {
+				errs = append(errs, &utils.KeyError{
+					Key: fmt.Sprintf("%s.%d.skills.%d", referrer.cardsKey(), cIdx, rIdx),
+					Err: fmt.Errorf("unknown skill '%s'", id),
+				})
+			}
+		}
+	}
+	return errs
+}
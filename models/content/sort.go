@@ -0,0 +1,196 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bossm8/portfoli.go/models/utils"
+)
+
+// The keys the cards can be sorted by (see SortOrder)
+const (
+	// SortManual keeps the order of the yaml file
+	SortManual = "manual"
+	// SortFrom sorts by the start of the date range
+	SortFrom = "from"
+	// SortTo sorts by the end of the date range, ongoing ranges (without end
+	// or with a text like 'now') are the latest
+	SortTo = "to"
+	// SortName sorts alphabetically by name
+	SortName = "name"
+)
+
+var (
+	sortOrdersMu sync.RWMutex
+	// sortOrders contains the configured sort orders by content type
+	sortOrders map[string]*SortOrder
+	// ongoing is the end of date ranges which have not ended yet
+	ongoing = time.Unix(1<<62, 0)
+)
+
+// SortOrder configures the order in which the cards of a content type are
+// listed, pinned cards always come first
+type SortOrder struct {
+	// By is the key to sort by: manual (the order of the yaml file, default),
+	// from, to or name
+	By string `yaml:"by" json:"by,omitempty"`
+	// Order is asc or desc, the default is desc for dates (latest first)
+	// and asc otherwise
+	Order string `yaml:"order" json:"order,omitempty"`
+}
+
+// Validate checks the key and order of the sort order
+func (s *SortOrder) Validate() error {
+	switch s.By {
+	case "", SortManual, SortFrom, SortTo, SortName:
+	default:
+		return &utils.KeyError{
+			Key: "by",
+			Err: fmt.Errorf("unknown sort key '%s' (expected %s, %s, %s or %s)", s.By, SortManual, SortFrom, SortTo, SortName),
+		}
+	}
+	switch s.Order {
+	case "", "asc", "desc":
+	default:
+		return &utils.KeyError{Key: "order", Err: fmt.Errorf("unknown sort order '%s' (expected asc or desc)", s.Order)}
+	}
+	return nil
+}
+
+// descending returns true if the cards are sorted in descending order
+func (s *SortOrder) descending() bool {
+	if s.Order == "" {
+		return s.By == SortFrom || s.By == SortTo
+	}
+	return s.Order == "desc"
+}
+
+// SetSortOrders replaces the sort orders of the content types (the keys)
+func SetSortOrders(orders map[string]*SortOrder) error {
+	for name, order := range orders {
+		if order == nil {
+			continue
+		}
+		if err := order.Validate(); err != nil {
+			return fmt.Errorf("sort order of %s: %w", name, err)
+		}
+	}
+	sortOrdersMu.Lock()
+	defer sortOrdersMu.Unlock()
+	sortOrders = maps.Clone(orders)
+	return nil
+}
+
+// SortOrders returns the current sort orders by content type
+func SortOrders() map[string]*SortOrder {
+	sortOrdersMu.RLock()
+	defer sortOrdersMu.RUnlock()
+	return maps.Clone(sortOrders)
+}
+
+// dateRange returns the date range itself, cards embedding it can be sorted by date
+func (d *CardDateRange) dateRange() *CardDateRange {
+	return d
+}
+
+// datedCard is implemented by all cards with a date range
+type datedCard interface {
+	dateRange() *CardDateRange
+}
+
+// sortDates returns the start and end of the date range of card, the end of
+// ongoing ranges is after all dates and cards without dates have zero dates
+func sortDates(card Card) (from time.Time, to time.Time) {
+	dated, ok := card.(datedCard)
+	if !ok {
+		return
+	}
+	d := dated.dateRange()
+	if d.From.IsZero() {
+		return
+	}
+	if end, ok := d.end(); ok {
+		return d.From.Time, end.Time
+	}
+	return d.From.Time, ongoing
+}
+
+// sortCards returns the cards of t in the configured order (see SortOrder),
+// the order of equal cards is kept
+func sortCards(cards []Card, t *Type) []Card {
+	sortOrdersMu.RLock()
+	order := sortOrders[t.Name]
+	sortOrdersMu.RUnlock()
+	if order == nil {
+		order = &SortOrder{}
+	}
+
+	sorted := slices.Clone(cards)
+	slices.SortStableFunc(sorted, func(a, b Card) int {
+		var res int
+		switch order.By {
+		case SortName:
+			res = cmp.Compare(strings.ToLower(a.Base().Name), strings.ToLower(b.Base().Name))
+		case SortFrom, SortTo:
+			fromA, toA := sortDates(a)
+			fromB, toB := sortDates(b)
+			if order.By == SortTo {
+				res = toA.Compare(toB)
+			}
+			if res == 0 {
+				res = fromA.Compare(fromB)
+			}
+		}
+		if order.descending() {
+			res = -res
+		}
+		return res
+	})
+	if (order.By == "" || order.By == SortManual) && order.Order == "desc" {
+		slices.Reverse(sorted)
+	}
+	// pinned cards first, in the sorted order
+	slices.SortStableFunc(sorted, func(a, b Card) int {
+		switch {
+		case a.Base().Pinned == b.Base().Pinned:
+			return 0
+		case a.Base().Pinned:
+			return -1
+		}
+		return 1
+	})
+	return sorted
+}
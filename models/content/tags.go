@@ -0,0 +1,160 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ErrTagNotFound is returned for tags which no card of a content type has
+var ErrTagNotFound = errors.New("tag not found")
+
+// Tag is a tag of the cards of a content type
+type Tag struct {
+	// Name is the tag as written in the first card using it
+	Name string
+	// Slug identifies the tag in paths, tags with the same slug are the same
+	// (e.g. Go and go)
+	Slug string
+	// Count is the number of cards with the tag
+	Count int
+}
+
+// TagUsage is the number of cards of a content type with a tag
+type TagUsage struct {
+	// Type is the content type and Title its title
+	Type  string
+	Title string
+	Count int
+	// Path is the path of the page listing the cards (e.g. /projects/tags/go)
+	Path string
+}
+
+// TagIndexEntry is a tag of the tag index, Count is the number of cards of
+// all content types with the tag
+type TagIndexEntry struct {
+	Tag
+	// Types lists the content types with cards with the tag
+	Types []*TagUsage
+}
+
+// collectTags returns the tags of the cards (sorted by name) and the cards
+// tagged with each one by the slug of the tag, the cards keep their order
+func collectTags(cards []Card) ([]*Tag, map[string][]Card) {
+	bySlug := make(map[string]*Tag)
+	tagged := make(map[string][]Card)
+	for _, card := range cards {
+		for _, name := range card.Base().Tags {
+			slug := slugify(name)
+			if slug == "" || slices.Contains(tagged[slug], card) {
+				continue
+			}
+			tag, ok := bySlug[slug]
+			if !ok {
+				tag = &Tag{Name: name, Slug: slug}
+				bySlug[slug] = tag
+			}
+			tag.Count++
+			tagged[slug] = append(tagged[slug], card)
+		}
+	}
+	tags := make([]*Tag, 0, len(bySlug))
+	for _, tag := range bySlug {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, compareTags)
+	return tags, tagged
+}
+
+// compareTags orders tags by name (case insensitive)
+func compareTags(a, b *Tag) int {
+	return cmp.Or(
+		cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)),
+		cmp.Compare(a.Slug, b.Slug),
+	)
+}
+
+// GetTags returns the tags of the cards of contentType sorted by name
+func GetTags(contentType string) ([]*Tag, error) {
+	rendered, err := getRendered(contentType)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.tags, nil
+}
+
+// GetRenderedContentByTag returns the rendered content of contentType listing
+// only the cards tagged with tag (a name or slug), ErrTagNotFound if there are none
+func GetRenderedContentByTag(contentType string, tag string) (*ContentTemplateData, error) {
+	rendered, err := getRendered(contentType)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := rendered.tagged[slugify(tag)]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+	cp := *data
+	return &cp, nil
+}
+
+// GetTagIndex returns the tags of the cards of all contentTypes sorted by
+// name, with the number of cards per content type
+func GetTagIndex(contentTypes []string) ([]*TagIndexEntry, error) {
+	bySlug := make(map[string]*TagIndexEntry)
+	for _, contentType := range contentTypes {
+		rendered, err := getRendered(contentType)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range rendered.tags {
+			entry, ok := bySlug[tag.Slug]
+			if !ok {
+				entry = &TagIndexEntry{Tag: Tag{Name: tag.Name, Slug: tag.Slug}}
+				bySlug[tag.Slug] = entry
+			}
+			entry.Count += tag.Count
+			entry.Types = append(entry.Types, &TagUsage{
+				Type:  contentType,
+				Title: rendered.content.Title,
+				Count: tag.Count,
+				Path:  "/" + contentType + "/tags/" + tag.Slug,
+			})
+		}
+	}
+	index := make([]*TagIndexEntry, 0, len(bySlug))
+	for _, entry := range bySlug {
+		index = append(index, entry)
+	}
+	slices.SortFunc(index, func(a, b *TagIndexEntry) int { return compareTags(&a.Tag, &b.Tag) })
+	return index, nil
+}
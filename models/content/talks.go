@@ -0,0 +1,109 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package content
+
+import (
+	"html/template"
+	"time"
+)
+
+func init() {
+	MustRegister(&Type{
+		Name:         "talks",
+		Title:        "Talks",
+		CardTemplate: "talk.html",
+		Pager:        7,
+		New:          func() ContentConfig { return &TalkConfig{} },
+	})
+}
+
+type TalkConfig struct {
+	TypeInfo `yaml:"-" json:"-"`
+	Talks    []*TalkCard `yaml:"talks" json:"talks,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ ContentConfig = &TalkConfig{}
+var _ CardContentConfig = &TalkConfig{}
+
+func (tc *TalkConfig) Elements() []Card {
+	return castToCard(tc.Talks)
+}
+
+func (tc *TalkConfig) setElements(cards []Card) {
+	tc.Talks = castFromCard[*TalkCard](cards)
+}
+
+// Validate checks the slugs of the cards
+func (tc *TalkConfig) Validate() error {
+	return checkSlugs("talks", tc.Elements())
+}
+
+func (tc *TalkConfig) Render() (*template.HTML, error) {
+	return renderCards(tc, tc.Type())
+}
+
+// TalkCard is a talk held at a conference, meetup etc.
+type TalkCard struct {
+	CardBase `yaml:",inline"`
+	// Event the talk was held at
+	Event string `yaml:"event" json:"event,omitempty" validate:"required"`
+	// Date of the talk, which may be written as 2021-03-14, 2021-03 or 2021
+	Date Date `yaml:"date" json:"date,omitempty" validate:"required"`
+	// Location of the event, e.g. 'Berlin' or 'Online'
+	Location string `yaml:"location" json:"location,omitempty"`
+	// Slides and Video are links to the recordings of the talk
+	Slides string `yaml:"slides" json:"slides,omitempty" validate:"url"`
+	Video  string `yaml:"video" json:"video,omitempty" validate:"url"`
+	// The format in which the date should be rendered (see CardDateRange)
+	Format string `yaml:"dateformat" json:"dateformat,omitempty"`
+}
+
+// Make sure the interface is implemented
+var _ Card = &TalkCard{}
+
+// dateRange returns the date of the talk, so talks can be sorted by date
+func (t *TalkCard) dateRange() *CardDateRange {
+	return &CardDateRange{From: t.Date, To: t.Date.Time, Format: t.Format}
+}
+
+// GetDateAsStr returns the date of the talk formatted as string
+func (t *TalkCard) GetDateAsStr() string {
+	return t.dateRange().GetFromDateAsStr()
+}
+
+// Ago returns the time passed since the talk, e.g. '3 years ago'
+func (t *TalkCard) Ago() string {
+	return t.dateRange().FromAgo()
+}
+
+// Upcoming returns true if the talk is yet to be held
+func (t *TalkCard) Upcoming() bool {
+	return time.Now().Before(t.Date.periodEnd())
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v3"
+)
+
+// The sanitizing policies which can be applied to rendered markdown
+const (
+	// PolicyUGC allows the html produced by markdown (and common formatting
+	// in inline html) but no scripts, styles or iframes
+	PolicyUGC = "ugc"
+	// PolicyStrict removes all html, leaving only text
+	PolicyStrict = "strict"
+	// PolicyNone does not sanitize the rendered markdown
+	PolicyNone = "none"
+
+	// DefaultHighlightStyle is the chroma style used for fenced code blocks
+	DefaultHighlightStyle = "github"
+)
+
+// The tags selecting the format of a single html field, e.g.
+// 'description: !markdown ...', they take precedence over the format of the file
+const (
+	markdownTag = "!markdown"
+	htmlTag     = "!html"
+)
+
+var (
+	// markdownFileRegex matches the comment which renders all html fields of a
+	// file as markdown (unless they are tagged with !html)
+	markdownFileRegex = regexp.MustCompile(`(?m)^#\s*portfoligo:markdown\s*$`)
+	// templateActionRegex matches template pipelines (e.g. {{ "/cv.pdf" | Assemble }})
+	// which are processed after rendering (see ProcessHTMLContent)
+	templateActionRegex = regexp.MustCompile(`(?s){{.*?}}`)
+	// placeholderRegex matches the placeholders replacing the template pipelines
+	// while rendering, they consist of letters and digits only so they neither
+	// change the markdown nor get escaped in link destinations
+	placeholderRegex = regexp.MustCompile(`portfoligotpl(\d+)x`)
+
+	htmlType = reflect.TypeOf(template.HTML(""))
+
+	markdownMu sync.RWMutex
+	markdown   goldmark.Markdown
+	policy     *bluemonday.Policy
+	// the options passed to SetMarkdownOptions
+	policyName     string
+	highlightStyle string
+)
+
+func init() {
+	if err := SetMarkdownOptions(PolicyUGC, DefaultHighlightStyle); err != nil {
+		panic(err)
+	}
+}
+
+// newPolicy returns the bluemonday policy with name, the ugc policy additionally
+// allows the inline styles of the syntax highlighting
+func newPolicy(name string) (*bluemonday.Policy, error) {
+	switch name {
+	case PolicyUGC, "":
+		p := bluemonday.UGCPolicy()
+		p.AllowStyles(
+			"color", "background-color", "font-weight", "font-style",
+			"text-decoration", "display", "padding", "margin", "border",
+			"white-space", "user-select", "width", "overflow-x",
+		).OnElements("pre", "code", "span")
+		return p, nil
+	case PolicyStrict:
+		return bluemonday.StrictPolicy(), nil
+	case PolicyNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown markdown policy '%s' (expected %s, %s or %s)", name, PolicyUGC, PolicyStrict, PolicyNone)
+}
+
+// newMarkdown returns the markdown renderer highlighting fenced code blocks
+// with the chroma style and the policy sanitizing its output, the errors are
+// KeyErrors for the keys policy and style
+func newMarkdown(policyName string, style string) (goldmark.Markdown, *bluemonday.Policy, error) {
+	p, err := newPolicy(policyName)
+	if err != nil {
+		return nil, nil, &KeyError{Key: "policy", Err: err}
+	}
+	if style == "" {
+		style = DefaultHighlightStyle
+	}
+	if _, ok := styles.Registry[style]; !ok {
+		return nil, nil, &KeyError{Key: "style", Err: fmt.Errorf("unknown highlight style '%s'", style)}
+	}
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			highlighting.NewHighlighting(highlighting.WithStyle(style)),
+		),
+		// raw html is allowed in markdown, the output is sanitized by the policy
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	return md, p, nil
+}
+
+// CheckMarkdownOptions returns an error if the policy or style passed to
+// SetMarkdownOptions would be rejected
+func CheckMarkdownOptions(policyName string, style string) error {
+	_, _, err := newMarkdown(policyName, style)
+	return err
+}
+
+// SetMarkdownOptions sets the sanitizing policy (ugc, strict or none) applied
+// to rendered markdown and the chroma style used to highlight fenced code blocks,
+// empty values select the defaults
+func SetMarkdownOptions(name string, style string) error {
+	md, p, err := newMarkdown(name, style)
+	if err != nil {
+		return err
+	}
+	markdownMu.Lock()
+	defer markdownMu.Unlock()
+	markdown, policy = md, p
+	policyName, highlightStyle = name, style
+	return nil
+}
+
+// MarkdownOptions returns the policy and style set with SetMarkdownOptions
+func MarkdownOptions() (string, string) {
+	markdownMu.RLock()
+	defer markdownMu.RUnlock()
+	return policyName, highlightStyle
+}
+
+// RenderMarkdown renders the markdown source to sanitized html. Template
+// pipelines are preserved, so e.g. links to '{{ "/static/cv.pdf" | Assemble }}'
+// still work once the html is passed to ProcessHTMLContent.
+func RenderMarkdown(source string) (template.HTML, error) {
+	var actions []string
+	source = templateActionRegex.ReplaceAllStringFunc(source, func(action string) string {
+		actions = append(actions, action)
+		return "portfoligotpl" + strconv.Itoa(len(actions)-1) + "x"
+	})
+
+	markdownMu.RLock()
+	md, p := markdown, policy
+	markdownMu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	rendered := buf.String()
+	if p != nil {
+		rendered = p.Sanitize(rendered)
+	}
+	rendered = placeholderRegex.ReplaceAllStringFunc(rendered, func(placeholder string) string {
+		idx, _ := strconv.Atoi(placeholderRegex.FindStringSubmatch(placeholder)[1])
+		if idx >= len(actions) {
+			return placeholder
+		}
+		return actions[idx]
+	})
+	return template.HTML(rendered), nil
+}
+
+// usesMarkdown returns true if the yaml data contains fields written in markdown
+func usesMarkdown(data []byte) bool {
+	return markdownFileRegex.Match(data) || bytes.Contains(data, []byte(markdownTag))
+}
+
+// renderMarkdownNodes renders the html fields (template.HTML) of the document
+// node decoded into t which are written in markdown. The values are replaced
+// in place, so the node can be decoded afterwards. Tags on other fields are
+// reported to report, which is also called if the rendering fails.
+func renderMarkdownNodes(
+	node *yaml.Node,
+	t reflect.Type,
+	path string,
+	fileMarkdown bool,
+	report func(node *yaml.Node, key string, err error),
+) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			renderMarkdownNodes(node.Content[0], t, path, fileMarkdown, report)
+		}
+		return
+	case yaml.AliasNode:
+		// the anchored node is rendered where it is defined
+		return
+	}
+
+	if node.Kind == yaml.ScalarNode && (node.Tag == markdownTag || node.Tag == htmlTag) {
+		if t != htmlType {
+			report(node, path, fmt.Errorf("%s is only supported on html fields", node.Tag))
+			return
+		}
+	}
+	if t == htmlType {
+		if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+			return
+		}
+		isMarkdown := node.Tag == markdownTag || (fileMarkdown && node.Tag != htmlTag)
+		if node.Tag == markdownTag || node.Tag == htmlTag {
+			node.Tag = "!!str"
+		}
+		if !isMarkdown {
+			return
+		}
+		rendered, err := RenderMarkdown(node.Value)
+		if err != nil {
+			report(node, path, err)
+			return
+		}
+		node.Value = string(rendered)
+		return
+	}
+	if isLeaf(t) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fs, _ := fields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if f, ok := fs[key.Value]; ok {
+				renderMarkdownNodes(value, f.typ, joinKey(path, key.Value), fileMarkdown, report)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind == yaml.SequenceNode {
+			for idx, item := range node.Content {
+				renderMarkdownNodes(item, t.Elem(), joinKey(path, strconv.Itoa(idx)), fileMarkdown, report)
+			}
+		}
+	case reflect.Map:
+		if node.Kind == yaml.MappingNode {
+			for i := 1; i < len(node.Content); i += 2 {
+				renderMarkdownNodes(node.Content[i], t.Elem(), joinKey(path, node.Content[i-1].Value), fileMarkdown, report)
+			}
+		}
+	}
+}
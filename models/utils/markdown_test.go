@@ -0,0 +1,130 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package utils
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testMarkdownConfig struct {
+	Name  string         `yaml:"name"`
+	Intro template.HTML  `yaml:"intro"`
+	Body  *template.HTML `yaml:"body"`
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	source := strings.Join([]string{
+		"Read my [CV]({{ \"/static/cv.pdf\" | Assemble }}).",
+		"",
+		"| a | b |",
+		"|---|---|",
+		"| 1 | 2 |",
+		"",
+		"```go",
+		"func main() {}",
+		"```",
+		"",
+		"<script>alert(1)</script>",
+	}, "\n")
+
+	rendered, err := RenderMarkdown(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(rendered)
+	for _, expected := range []string{
+		`<a href="{{ "/static/cv.pdf" | Assemble }}"`,
+		"<table>",
+		"<td>1</td>",
+		`<span style="color:`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q in rendered markdown:\n%s", expected, html)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("expected scripts to be sanitized:\n%s", html)
+	}
+
+	if err := SetMarkdownOptions(PolicyNone, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer SetMarkdownOptions(PolicyUGC, "")
+	if rendered, _ := RenderMarkdown(source); !strings.Contains(string(rendered), "<script>") {
+		t.Errorf("expected html not to be sanitized with policy none:\n%s", rendered)
+	}
+
+	if err := CheckMarkdownOptions("lax", ""); err == nil {
+		t.Error("expected unknown policies to be rejected")
+	}
+	if err := CheckMarkdownOptions("", "no-such-style"); err == nil {
+		t.Error("expected unknown styles to be rejected")
+	}
+}
+
+func TestLoadFromYAMLFileMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	SetYAMLDir(dir)
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "test.yml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("name: '**name**'\nintro: !markdown '**intro**'\nbody: '**body**'\n")
+	cfg := &testMarkdownConfig{}
+	if err := LoadFromYAMLFile("test.yml", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "**name**" || cfg.Intro != "<p><strong>intro</strong></p>\n" || *cfg.Body != "**body**" {
+		t.Errorf("expected only the tagged field to be rendered, got %+v (body %q)", cfg, *cfg.Body)
+	}
+
+	write("# portfoligo:markdown\nname: '**name**'\nintro: !html '**intro**'\nbody: '**body**'\n")
+	cfg = &testMarkdownConfig{}
+	if err := LoadFromYAMLFile("test.yml", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "**name**" || cfg.Intro != "**intro**" || *cfg.Body != "<p><strong>body</strong></p>\n" {
+		t.Errorf("expected the html fields not tagged !html to be rendered, got %+v (body %q)", cfg, *cfg.Body)
+	}
+
+	write("name: !markdown '**name**'\n")
+	problems, err := ValidateYAMLFile("test.yml", &testMarkdownConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.HasPrefix(problems[0].String(), filepath.Join(dir, "test.yml")+":1:7: !markdown is only supported") {
+		t.Errorf("expected the tag on a text field to be reported, got %v", problems)
+	}
+}
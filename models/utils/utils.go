@@ -29,9 +29,15 @@
 package utils
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 )
@@ -39,24 +45,80 @@ import (
 // The directory where all (static and dynamic) configuration files are read from
 var yamlDir string
 
+// strict enables rejecting unknown keys in the yaml files
+var strict bool
+
+// allowUnknownKeysRegex matches the comment which disables the strict mode
+// for a single file, e.g. if it contains custom keys for own templates
+var allowUnknownKeysRegex = regexp.MustCompile(`(?m)^#\s*portfoligo:allow-unknown-keys\s*$`)
+
 // SetYAMLDir sets the configuration directory where dynamic and static configurations
 // should be read from
 func SetYAMLDir(dir string) {
 	yamlDir = dir
 }
 
-// LoadFromYAMLFile loads the file with filename into obj
+// YAMLFileExists returns true if the file with filename exists in the configuration directory
+func YAMLFileExists(filename string) bool {
+	_, err := os.Stat(filepath.Join(yamlDir, filename))
+	return err == nil
+}
+
+// SetStrict enables or disables the strict mode, in which unknown keys in
+// the yaml files are reported as errors (unless the file contains the
+// comment '# portfoligo:allow-unknown-keys')
+func SetStrict(enabled bool) {
+	strict = enabled
+}
+
+// rejectUnknownKeys returns true if unknown keys in the yaml data are errors
+func rejectUnknownKeys(data []byte) bool {
+	return strict && !allowUnknownKeysRegex.Match(data)
+}
+
+// LoadFromYAMLFile loads the file with filename into obj, html fields written
+// in markdown are rendered (see RenderMarkdown)
 func LoadFromYAMLFile(filename string, obj interface{}) (err error) {
-	// log.Printf("[INFO] Loading yaml file '%s' from directory '%s'\n", filename, configDir)
+	slog.Debug("Loading yaml file", "file", filename, "dir", yamlDir)
 	var yamlFile []byte
 	if yamlFile, err = os.ReadFile(
 		filepath.Join(yamlDir, filename),
 	); err != nil {
-		log.Printf("[ERROR]: Failed to load yaml file '%s': %s\n", filename, err)
+		slog.Error("Failed to load yaml file", "file", filename, "error", err)
 		return
 	}
-	if err = yaml.Unmarshal(yamlFile, obj); err != nil {
-		log.Printf("[ERROR]: Failed to parse yaml file '%s': %s\n", filename, err)
+	dec := yaml.NewDecoder(bytes.NewReader(yamlFile))
+	dec.KnownFields(rejectUnknownKeys(yamlFile))
+	if err = dec.Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("Failed to parse yaml file", "file", filename, "error", err)
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if usesMarkdown(yamlFile) {
+		return loadMarkdown(filename, yamlFile, obj)
+	}
+	return nil
+}
+
+// loadMarkdown decodes data into obj again after rendering its markdown fields,
+// the keys were already checked when decoding the data the first time
+func loadMarkdown(filename string, data []byte, obj interface{}) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	var errs []error
+	renderMarkdownNodes(&root, reflect.TypeOf(obj), "", markdownFileRegex.Match(data),
+		func(node *yaml.Node, key string, err error) {
+			errs = append(errs, fmt.Errorf("%s: line %d: %s: %w", filename, node.Line, key, err))
+		},
+	)
+	if err := errors.Join(errs...); err != nil {
+		slog.Error("Failed to render markdown", "file", filename, "error", err)
+		return err
+	}
+	if err := root.Decode(obj); err != nil {
+		slog.Error("Failed to parse yaml file", "file", filename, "error", err)
+		return fmt.Errorf("%s: %w", filename, err)
 	}
-	return
+	return nil
 }
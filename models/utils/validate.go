@@ -0,0 +1,508 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appconfig "github.com/bossm8/portfoli.go/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an issue found while validating a yaml file
+type Problem struct {
+	File   string
+	Line   int
+	Column int
+	// Key is the path of the yaml key the problem concerns (e.g.
+	// smtp.pass or experiences.0.name), empty if unknown
+	Key     string
+	Message string
+}
+
+// String returns the problem as file:line:column: message
+func (p Problem) String() string {
+	switch {
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+// Validator is implemented by types with checks spanning several fields,
+// which are run by ValidateYAMLFile after the yaml was decoded
+type Validator interface {
+	Validate() error
+}
+
+// KeyError can be returned by Validate to report a problem at the position
+// of the yaml key instead of the object containing it
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// rules contains the checks available in the validate struct tag, e.g.
+// `validate:"required,url"`, they are applied to every non empty scalar
+// (required is handled separately as it concerns missing keys too)
+var rules = map[string]func(value string) error{
+	"url":   checkURL,
+	"email": checkEmail,
+	"image": checkImage,
+}
+
+// isTemplate returns true if value contains template pipelines, which are
+// only resolved when rendering (e.g. Assemble)
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// checkURL accepts absolute urls and absolute paths
+func checkURL(value string) error {
+	if isTemplate(value) {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	switch {
+	case u.Scheme == "mailto" || u.Scheme == "tel":
+		return nil
+	case u.Scheme != "" && u.Host == "":
+		return fmt.Errorf("invalid url '%s': missing host", value)
+	case u.Scheme == "" && !strings.HasPrefix(value, "/"):
+		return fmt.Errorf("invalid url '%s': must be absolute (e.g. https://%s) or start with /", value, value)
+	}
+	return nil
+}
+
+func checkEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("invalid email address '%s': %w", value, err)
+	}
+	return nil
+}
+
+// checkImage checks the url of the image and that images below /static
+// exist in the static dir
+func checkImage(value string) error {
+	if err := checkURL(value); err != nil || isTemplate(value) {
+		return err
+	}
+	rel, ok := strings.CutPrefix(value, "/static/")
+	if !ok {
+		return nil
+	}
+	rel, _, _ = strings.Cut(rel, "?")
+	if _, err := os.Stat(filepath.Join(appconfig.StaticContentPath(), filepath.FromSlash(rel))); err != nil {
+		return fmt.Errorf("image '%s' not found in the static dir (%s)", value, appconfig.StaticContentPath())
+	}
+	return nil
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+	// errLineRegex extracts the line from errors returned by yaml.v3
+	errLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// field is a struct field with the yaml key it is decoded from
+type field struct {
+	typ   reflect.Type
+	rules []string
+}
+
+// validator collects the problems of a single yaml file
+type validator struct {
+	file string
+	// strict reports unknown keys (see SetStrict)
+	strict   bool
+	problems []Problem
+}
+
+func (v *validator) add(node *yaml.Node, key string, format string, args ...interface{}) {
+	p := Problem{File: v.file, Key: key, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		p.Line, p.Column = node.Line, node.Column
+	}
+	v.problems = append(v.problems, p)
+}
+
+// addDecodeError adds the errors returned by yaml.v3, which contain the
+// line but no column
+func (v *validator) addDecodeError(err error) {
+	var msgs []string
+	if typeErr := (&yaml.TypeError{}); errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+	for _, msg := range msgs {
+		p := Problem{File: v.file, Message: msg}
+		if match := errLineRegex.FindStringSubmatch(msg); match != nil {
+			p.Line, _ = strconv.Atoi(match[1])
+			p.Message = match[2]
+		}
+		v.problems = append(v.problems, p)
+	}
+}
+
+// ValidateYAMLFile decodes the file with filename into obj and reports all
+// problems found with their position: decoding errors, keys which are not
+// known to obj (in strict mode, see SetStrict), values violating the rules in the validate struct tags and
+// the errors returned by types implementing Validator. The error is only set
+// if the file could not be read.
+func ValidateYAMLFile(filename string, obj interface{}) ([]Problem, error) {
+	path := filepath.Join(yamlDir, filename)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{file: path, strict: rejectUnknownKeys(data)}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		v.addDecodeError(err)
+		return v.problems, nil
+	}
+	if usesMarkdown(data) {
+		renderMarkdownNodes(&root, reflect.TypeOf(obj), "", markdownFileRegex.Match(data),
+			func(node *yaml.Node, key string, err error) {
+				v.add(node, key, "%s", err)
+			},
+		)
+	}
+	if err := root.Decode(obj); err != nil {
+		v.addDecodeError(err)
+	}
+	v.walk(&root, reflect.TypeOf(obj), "")
+
+	// drop decoding errors which were reported with their column by walk
+	// (some errors, e.g. of dates, do not even contain the line)
+	reported := make(map[string]bool)
+	for _, p := range v.problems {
+		if p.Column > 0 {
+			reported[p.Message] = true
+			reported[fmt.Sprintf("%d:%s", p.Line, p.Message)] = true
+		}
+	}
+	v.problems = slices.DeleteFunc(v.problems, func(p Problem) bool {
+		if p.Column > 0 {
+			return false
+		}
+		return reported[fmt.Sprintf("%d:%s", p.Line, p.Message)] || (p.Line == 0 && reported[p.Message])
+	})
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Column < v.problems[j].Column
+	})
+	return v.problems, nil
+}
+
+// LocateProblems returns errs found in the file with filename by checks
+// outside of ValidateYAMLFile (e.g. of references to other files) as
+// problems, KeyErrors are reported at the position of their key
+func LocateProblems(filename string, errs []error) ([]Problem, error) {
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	path := filepath.Join(yamlDir, filename)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	doc := &root
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		doc = root.Content[0]
+	}
+	v := &validator{file: path}
+	for _, err := range errs {
+		if keyErr := (&KeyError{}); errors.As(err, &keyErr) {
+			v.add(findKey(doc, keyErr.Key), keyErr.Key, "%s", err)
+		} else {
+			v.add(nil, "", "%s", err)
+		}
+	}
+	return v.problems, nil
+}
+
+// fields returns the yaml keys of the struct type t (including inlined
+// structs), open is true if t inlines a map and thus accepts any key
+func fields(t reflect.Type) (fs map[string]field, open bool) {
+	fs = make(map[string]field)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Map {
+				open = true
+				continue
+			}
+			inlined, inlinedOpen := fields(ft)
+			for k, v := range inlined {
+				fs[k] = v
+			}
+			open = open || inlinedOpen
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		var fieldRules []string
+		if tag := f.Tag.Get("validate"); tag != "" {
+			fieldRules = strings.Split(tag, ",")
+		}
+		fs[name] = field{typ: f.Type, rules: fieldRules}
+	}
+	return
+}
+
+// isLeaf returns true for types which are decoded from a single node
+// without further structure to check
+func isLeaf(t reflect.Type) bool {
+	return t == timeType ||
+		t.Implements(unmarshalerType) ||
+		reflect.PointerTo(t).Implements(unmarshalerType) ||
+		t.Kind() == reflect.Interface
+}
+
+// isEmpty returns true if the node does not contain a value
+func isEmpty(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Tag == "!!null" || strings.TrimSpace(node.Value) == ""
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
+	}
+	return false
+}
+
+// joinKey appends name to the key path
+func joinKey(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// walk checks node against the type t it is decoded into, path is the key
+// path of node
+func (v *validator) walk(node *yaml.Node, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			v.walk(node.Content[0], t, path)
+		}
+		return
+	case yaml.AliasNode:
+		v.walk(node.Alias, t, path)
+		return
+	}
+	if isLeaf(t) {
+		// decode custom types and dates on their own, to report errors with
+		// their position
+		if t.Kind() != reflect.Interface {
+			if err := node.Decode(reflect.New(t).Interface()); err != nil {
+				msg := err.Error()
+				if match := errLineRegex.FindStringSubmatch(msg); match != nil {
+					msg = match[2]
+				}
+				v.add(node, path, "%s", msg)
+			}
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind == yaml.MappingNode {
+			v.walkStruct(node, t, path)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind == yaml.SequenceNode {
+			for idx, item := range node.Content {
+				v.walk(item, t.Elem(), joinKey(path, strconv.Itoa(idx)))
+			}
+		}
+	case reflect.Map:
+		if node.Kind == yaml.MappingNode {
+			for i := 1; i < len(node.Content); i += 2 {
+				v.walk(node.Content[i], t.Elem(), joinKey(path, node.Content[i-1].Value))
+			}
+		}
+	}
+}
+
+// walkStruct checks the keys of the mapping node against the struct type t
+func (v *validator) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fs, open := fields(t)
+	present := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		f, ok := fs[key.Value]
+		if !ok {
+			if !open && v.strict {
+				v.add(key, joinKey(path, key.Value), "unknown key '%s'", key.Value)
+			}
+			continue
+		}
+		present[key.Value] = true
+		v.checkRules(joinKey(path, key.Value), value, f.rules)
+		v.walk(value, f.typ, joinKey(path, key.Value))
+	}
+
+	for name, f := range fs {
+		for _, rule := range f.rules {
+			if rule == "required" && !present[name] {
+				v.add(node, joinKey(path, name), "missing required key '%s'", name)
+			}
+		}
+	}
+
+	// a failed decoding was already reported
+	obj := reflect.New(t)
+	if err := node.Decode(obj.Interface()); err != nil {
+		return
+	}
+	if val, ok := obj.Interface().(Validator); ok {
+		if err := val.Validate(); err != nil {
+			at, key := node, path
+			if keyErr := (&KeyError{}); errors.As(err, &keyErr) {
+				at, key = findKey(node, keyErr.Key), joinKey(path, keyErr.Key)
+			}
+			v.add(at, key, "%s", err)
+		}
+	}
+}
+
+// checkRules applies the rules of a field to value (or to every element
+// if value is a sequence), key is the path of the field
+func (v *validator) checkRules(key string, value *yaml.Node, fieldRules []string) {
+	name := key[strings.LastIndex(key, ".")+1:]
+	for _, rule := range fieldRules {
+		if rule == "required" {
+			if isEmpty(value) {
+				v.add(value, key, "missing value for required key '%s'", name)
+			}
+			continue
+		}
+		check, ok := rules[rule]
+		if !ok {
+			// programmer error, the tags are static
+			panic("unknown validation rule " + rule)
+		}
+		values := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			values = value.Content
+		}
+		for _, val := range values {
+			if val.Kind != yaml.ScalarNode || isEmpty(val) {
+				continue
+			}
+			if err := check(strings.TrimSpace(val.Value)); err != nil {
+				v.add(val, key, "%s: %s", name, err)
+			}
+		}
+	}
+}
+
+// findKey returns the node of key in the mapping node, key may be a path
+// of nested keys and sequence indexes (e.g. profile.content or projects.2.slug),
+// node itself is returned if the key is not present
+func findKey(node *yaml.Node, key string) *yaml.Node {
+	name, rest, nested := strings.Cut(key, ".")
+	if node.Kind == yaml.SequenceNode {
+		idx, err := strconv.Atoi(name)
+		if err != nil || idx < 0 || idx >= len(node.Content) {
+			return node
+		}
+		if nested {
+			return findKey(node.Content[idx], rest)
+		}
+		return node.Content[idx]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != name {
+			continue
+		}
+		if value := node.Content[i+1]; nested && (value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode) {
+			if found := findKey(value, rest); found != value {
+				return found
+			}
+		}
+		return node.Content[i]
+	}
+	return node
+}
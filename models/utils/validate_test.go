@@ -0,0 +1,166 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	Name  string    `yaml:"name" validate:"required"`
+	Link  string    `yaml:"link" validate:"url"`
+	From  time.Time `yaml:"from"`
+	Until time.Time `yaml:"until"`
+}
+
+func (e *testEntry) Validate() error {
+	if !e.Until.IsZero() && e.From.After(e.Until) {
+		return &KeyError{Key: "until", Err: errors.New("before from")}
+	}
+	return nil
+}
+
+type testConfig struct {
+	Entries []*testEntry `yaml:"entries"`
+}
+
+func TestValidateYAMLFile(t *testing.T) {
+	dir := t.TempDir()
+	SetYAMLDir(dir)
+	SetStrict(true)
+	defer SetStrict(false)
+
+	yml := strings.Join([]string{
+		"entries:",
+		"  - name: valid",
+		"    link: https://example.com",
+		"  - link: example.com",
+		"    nmae: typo",
+		"  - name: dates",
+		"    from: 2023-01-01",
+		"    until: 2022-01-01",
+		"  - name: broken",
+		"    from: yesterday",
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(dir, "test.yml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := ValidateYAMLFile("test.yml", &testConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "test.yml")
+	expected := []string{
+		file + ":4:5: missing required key 'name'",
+		file + ":4:11: link: invalid url 'example.com': must be absolute (e.g. https://example.com) or start with /",
+		file + ":5:5: unknown key 'nmae'",
+		file + ":8:5: until: before from",
+		file + ":10:11: parsing time",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, problem := range problems {
+		if !strings.HasPrefix(problem.String(), expected[i]) {
+			t.Errorf("expected problem %q, got %q", expected[i], problem)
+		}
+	}
+}
+
+func TestValidateYAMLFileSyntax(t *testing.T) {
+	dir := t.TempDir()
+	SetYAMLDir(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "test.yml"), []byte("entries:\n  - name: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := ValidateYAMLFile("test.yml", &testConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Line != 2 {
+		t.Fatalf("expected a single problem on line 2, got %v", problems)
+	}
+}
+
+func TestLocateProblems(t *testing.T) {
+	dir := t.TempDir()
+	SetYAMLDir(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "test.yml"), []byte("entries:\n  - name: a\n  - name: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := LocateProblems("test.yml", []error{&KeyError{Key: "entries.1.name", Err: errors.New("unknown")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Line != 3 || problems[0].Column != 5 {
+		t.Fatalf("expected a single problem at 3:5, got %v", problems)
+	}
+}
+
+func TestLoadFromYAMLFileStrict(t *testing.T) {
+	dir := t.TempDir()
+	SetYAMLDir(dir)
+	defer SetStrict(false)
+
+	yml := "entries:\n  - name: typo\n    lnk: https://example.com\n"
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "test.yml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(yml)
+
+	SetStrict(false)
+	if err := LoadFromYAMLFile("test.yml", &testConfig{}); err != nil {
+		t.Errorf("unknown keys must be ignored if not strict, got %v", err)
+	}
+
+	SetStrict(true)
+	err := LoadFromYAMLFile("test.yml", &testConfig{})
+	if err == nil || !strings.Contains(err.Error(), "test.yml") || !strings.Contains(err.Error(), "line 3: field lnk not found") {
+		t.Errorf("expected the unknown key to be reported with file and line, got %v", err)
+	}
+
+	write("# portfoligo:allow-unknown-keys\n" + yml)
+	if err := LoadFromYAMLFile("test.yml", &testConfig{}); err != nil {
+		t.Errorf("unknown keys must be ignored in files allowing them, got %v", err)
+	}
+	if problems, _ := ValidateYAMLFile("test.yml", &testConfig{}); len(problems) != 0 {
+		t.Errorf("unknown keys must not be reported in files allowing them, got %v", problems)
+	}
+}
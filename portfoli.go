@@ -30,92 +30,95 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-
-	"github.com/bossm8/portfoli.go/config"
-	"github.com/bossm8/portfoli.go/server"
-	"github.com/bossm8/portfoli.go/static"
+	"strings"
 )
 
-func main() {
+// command is a subcommand of the portfoli-go binary
+type command struct {
+	// name used on the command line
+	name string
+	// summary is the one line description shown in the overview
+	summary string
+	// run executes the command with the arguments following its name
+	run func(args []string) error
+}
 
-	cfgDir, err := os.UserConfigDir()
-	if err != nil {
-		log.Fatalf(
-			"[ERROR] Could not find the  user config dir, please provide the path to the configuration files manually (%s)",
-			err,
-		)
+// commands returns all available subcommands
+func commands() []*command {
+	return []*command{
+		{name: "serve", summary: "Serve the portfolio dynamically", run: runServe},
+		{name: "build", summary: "Create a static website build to e.g. host on GitLab pages", run: runBuild},
+		{name: "validate", summary: "Validate the configuration, content and templates", run: runValidate},
+		{name: "init", summary: "Create a new configuration directory from the examples", run: runInit},
+		{name: "export", summary: "Export the profile and content as JSON", run: runExport},
+		{name: "version", summary: "Print the version information", run: runVersion},
 	}
-	addr := flag.String(
-		"srv.address",
-		"127.0.0.1",
-		"Listen address for the protfolio server",
-	)
-	port := flag.Int(
-		"srv.port",
-		8080,
-		"Listen port for the portfolio server",
-	)
-	basePath := flag.String(
-		"srv.base",
-		"/",
-		"The base path to serve content on",
-	)
-	configDir := flag.String(
-		"config.dir",
-		filepath.Join(cfgDir, "portfoli.go", "configs"),
-		"Path to the directory containing the yaml configurations",
-	)
-	staticDir := flag.String(
-		"static.dir",
-		config.StaticContentPath(),
-		"Path to the directory containing the static content for the website",
-	)
-	templatesDir := flag.String(
-		"templates.dir",
-		config.TemplatesPath(),
-		"Path to the directory containing the html templates",
-	)
-	distDir := flag.String(
-		"dist.dir",
-		config.DistDir(),
-		"Path to the directory of where to output the static build",
-	)
-	imageCacheDir := flag.String(
-		"images.cache.dir",
-		"",
-		"Path to the directory where cached images are stored (default: img/cache, relative to static dir only)",
-	)
-	verbose := flag.Bool(
-		"verbose",
-		false,
-		"Print more verbose logging information (filenames)",
-	)
-	dist := flag.Bool(
-		"dist",
-		false,
-		"Create a static website build to e.g. host on GitLab pages",
-	)
-	flag.Parse()
+}
+
+// lookupCommand returns the command called name or nil if there is none
+func lookupCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
 
-	if *verbose {
-		log.SetFlags(log.Lshortfile)
+// usage prints the overview of all commands to w
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", programName())
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(w, "\nRun '%s <command> -help' for the flags of a command.\n", programName())
+}
+
+// programName returns the name the binary was called with
+func programName() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
 
-	*configDir = config.ConvertToAbsPath(configDir)
-	log.Printf("[INFO] using config path %s\n", *configDir)
+func main() {
+	args := os.Args[1:]
 
-	if *dist {
-		config.SetPaths(templatesDir, staticDir, distDir)
-		static.Build(*basePath, *configDir, *imageCacheDir)
-	} else {
-		// Do not log the dist dir path by using nil
-		config.SetPaths(templatesDir, staticDir, nil)
-		server.StartServer(fmt.Sprintf("%s:%d", *addr, *port), *basePath, *configDir, *imageCacheDir)
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			if len(args) > 1 {
+				if cmd := lookupCommand(args[1]); cmd != nil {
+					exit(cmd.run([]string{"-help"}))
+				}
+			}
+			usage(os.Stdout)
+			return
+		}
+		if cmd := lookupCommand(args[0]); cmd != nil {
+			exit(cmd.run(args[1:]))
+			return
+		}
+		if !strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n", args[0])
+			usage(os.Stderr)
+			os.Exit(2)
+		}
 	}
 
+	exit(runLegacy(args))
+}
+
+// exit terminates the program with a non-zero exit code if err is set
+func exit(err error) {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	slog.Error("Command failed", "error", err)
+	os.Exit(1)
 }
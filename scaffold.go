@@ -0,0 +1,90 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bossm8/portfoli.go/config"
+)
+
+// exampleConfigs are the configurations written by the init command
+//
+//go:embed examples/configs/*.yml
+var exampleConfigs embed.FS
+
+// runInit writes the example configurations to the config dir, so they can
+// be adjusted to the own needs
+func runInit(args []string) error {
+	flags := newFlagSet("init", "Create a new configuration directory from the example configurations.")
+	configDir := addConfigDirFlag(flags)
+	force := flags.Bool(
+		"force",
+		false,
+		"Overwrite existing configuration files",
+	)
+	flags.Parse(args)
+
+	dir := config.ConvertToAbsPath(configDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(exampleConfigs, "examples/configs/*.yml")
+	if err != nil {
+		return err
+	}
+	// check all files first, so nothing is written if one exists already
+	for _, file := range files {
+		dest := filepath.Join(dir, filepath.Base(file))
+		if _, err := os.Stat(dest); err == nil && !*force {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", dest)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	for _, file := range files {
+		dest := filepath.Join(dir, filepath.Base(file))
+		data, err := exampleConfigs.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+		fmt.Println("Created", dest)
+	}
+	fmt.Printf("\nAdjust the configurations and run '%s serve -config.dir %s'\n", programName(), dir)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	appconfig "github.com/bossm8/portfoli.go/config"
+
+	"github.com/bossm8/portfoli.go/models/content"
+	"github.com/bossm8/portfoli.go/utils"
+)
+
+const (
+	// smtpPingTimeout is the maximum duration the readiness check waits for the smtp server
+	smtpPingTimeout = 5 * time.Second
+
+	checkOK      = "ok"
+	checkSkipped = "skipped"
+)
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func writeHealth(w http.ResponseWriter, resp *healthResponse) {
+	status := http.StatusOK
+	if resp.Status != checkOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Warn("Failed to write health response", "error", err)
+	}
+}
+
+// serveHealth reports if the server process is alive
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, &healthResponse{Status: checkOK})
+}
+
+// serveReady reports if the server is able to serve the portfolio, i.e. if
+// the templates parse, the enabled content configurations load and if
+// configured, the smtp server accepts connections. It does not hold the
+// snapshot lock, as pinging the smtp server may take a while
+func serveReady(w http.ResponseWriter, r *http.Request) {
+	cfg := currentConfig()
+	resp := &healthResponse{
+		Status: checkOK,
+		Checks: make(map[string]string),
+	}
+	check := func(name string, err error) {
+		if err == nil {
+			resp.Checks[name] = checkOK
+			return
+		}
+		slog.Warn("Readiness check failed", "check", name, "error", err)
+		resp.Checks[name] = err.Error()
+		resp.Status = "unavailable"
+	}
+
+	check("templates", utils.CheckTemplates(appconfig.TemplatesPath()))
+	for _, contentType := range cfg.Profile.ContentTypes {
+		check("content:"+contentType, content.CheckContent(contentType))
+	}
+	if cfg.RenderContact && cfg.Server.Readiness.SMTP {
+		check("smtp", cfg.SMTP.Ping(smtpPingTimeout))
+	} else {
+		resp.Checks["smtp"] = checkSkipped
+	}
+
+	writeHealth(w, resp)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bossm8/portfoli.go/utils"
+)
+
+const (
+	// liveReloadEndpoint is the path of the server-sent events stream
+	liveReloadEndpoint = "/_livereload"
+	// liveReloadKeepAlive is the interval of comments sent to keep the stream open
+	liveReloadKeepAlive = 15 * time.Second
+	// liveReloadScript is injected into every page in development mode, it
+	// reloads the page when the server reports changes
+	liveReloadScript = `<script>
+(function () {
+    var source = new EventSource('%s');
+    source.addEventListener('reload', function () { window.location.reload(); });
+})();
+</script>
+`
+)
+
+// liveReload broadcasts reload events to all connected browsers
+type liveReload struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+	// done is closed when the server shuts down, http.Server.Shutdown does
+	// not cancel the request contexts and would wait for the open streams
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var reloader = &liveReload{
+	clients: make(map[chan struct{}]bool),
+	done:    make(chan struct{}),
+}
+
+func (l *liveReload) subscribe() chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	l.clients[ch] = true
+	return ch
+}
+
+func (l *liveReload) unsubscribe(ch chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, ch)
+}
+
+// notify tells all connected browsers to reload
+func (l *liveReload) notify() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.clients {
+		// a pending event is enough, do not block on slow clients
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	if len(l.clients) > 0 {
+		slog.Debug("Sent live reload event", "clients", len(l.clients))
+	}
+}
+
+// shutdown ends all open event streams
+func (l *liveReload) shutdown() {
+	l.closeOnce.Do(func() { close(l.done) })
+}
+
+// serveLiveReload streams reload events to the browser as server-sent events
+func serveLiveReload(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// the stream stays open, the server write timeout must not close it
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("Could not disable write deadline for live reload", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.Warn("Live reload requires a flushable response", "error", err)
+		return
+	}
+
+	events := reloader.subscribe()
+	defer reloader.unsubscribe(events)
+
+	keepAlive := time.NewTicker(liveReloadKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-reloader.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-events:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// injectLiveReload adds the live reload script to the end of the body of a
+// rendered page, if development mode is enabled
+func injectLiveReload(page []byte) []byte {
+	if !utils.DevMode() {
+		return page
+	}
+	script := fmt.Sprintf(liveReloadScript, utils.Assemble(liveReloadEndpoint))
+	idx := bytes.LastIndex(page, []byte("</body>"))
+	if idx < 0 {
+		return append(page, script...)
+	}
+	res := make([]byte, 0, len(page)+len(script))
+	res = append(res, page[:idx]...)
+	res = append(res, script...)
+	return append(res, page[idx:]...)
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	appconfig "github.com/bossm8/portfoli.go/config"
+
+	"github.com/bossm8/portfoli.go/messages"
+	"github.com/bossm8/portfoli.go/models"
+	"github.com/bossm8/portfoli.go/models/config"
+	"github.com/bossm8/portfoli.go/models/content"
+	modelutils "github.com/bossm8/portfoli.go/models/utils"
+	"github.com/bossm8/portfoli.go/utils"
+	"github.com/bossm8/portfoli.go/watcher"
+)
+
+// snapshotMu guards the snapshot the server renders from, i.e. the
+// configuration, the template registry and the rendered content, requests
+// rendering pages hold the read lock, so a reload is visible atomically
+var snapshotMu sync.RWMutex
+
+// withSnapshot is a middleware which makes sure the request is served from
+// one snapshot, even if a reload happens in the meantime
+func withSnapshot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshotMu.RLock()
+		defer snapshotMu.RUnlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// currentConfig returns the configuration of the current snapshot, for
+// handlers which must not hold the snapshot lock (e.g. while sending mails)
+func currentConfig() *config.Config {
+	snapshotMu.RLock()
+	defer snapshotMu.RUnlock()
+	return cfg
+}
+
+// settings are the package level settings changed while loading a
+// configuration (see config.Load and loadConfig), they belong to the
+// snapshot and are restored if it is rejected
+type settings struct {
+	customTypes  []*content.CustomType
+	enabledTypes []string
+	sortOrders   map[string]*content.SortOrder
+	dateConfig   *content.DateConfig
+	imageCache   utils.ImageCacheState
+	// markdownPolicy and markdownStyle are the markdown options
+	markdownPolicy string
+	markdownStyle  string
+}
+
+func currentSettings() *settings {
+	s := &settings{
+		customTypes:  content.CustomTypes(),
+		enabledTypes: content.EnabledTypes(),
+		sortOrders:   content.SortOrders(),
+		dateConfig:   content.CurrentDateConfig(),
+		imageCache:   utils.CurrentImageCache(),
+	}
+	s.markdownPolicy, s.markdownStyle = modelutils.MarkdownOptions()
+	return s
+}
+
+// restore applies the settings again, they were valid when captured
+func (s *settings) restore() {
+	if err := modelutils.SetMarkdownOptions(s.markdownPolicy, s.markdownStyle); err != nil {
+		slog.Error("Failed to restore the previous markdown options", "error", err)
+	}
+	if err := content.SetCustomTypes(s.customTypes); err != nil {
+		slog.Error("Failed to restore the previous custom content types", "error", err)
+	}
+	content.SetEnabledTypes(s.enabledTypes)
+	if err := content.SetSortOrders(s.sortOrders); err != nil {
+		slog.Error("Failed to restore the previous sort orders", "error", err)
+	}
+	if err := content.SetDateConfig(s.dateConfig); err != nil {
+		slog.Error("Failed to restore the previous date configuration", "error", err)
+	}
+	utils.RestoreImageCache(s.imageCache)
+}
+
+// loadSnapshot loads the configuration from configDir and activates it, the
+// snapshot lock is held the whole time, as loading already changes the
+// package level settings, they are restored if anything fails
+func loadSnapshot(configDir string, imageCacheDir string) error {
+	if err := utils.CheckTemplates(appconfig.TemplatesPath()); err != nil {
+		return err
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+
+	prev := currentSettings()
+	newCfg, err := loadConfig(configDir, imageCacheDir)
+	if err == nil {
+		err = activate(newCfg)
+	}
+	if err != nil {
+		prev.restore()
+		return err
+	}
+	return nil
+}
+
+// loadConfig loads and prepares the configuration from configDir, a missing
+// smtp configuration is not an error, the contact form is disabled instead
+func loadConfig(configDir string, imageCacheDir string) (*config.Config, error) {
+	newCfg, err := models.LoadConfiguration(configDir)
+	if err != nil && errors.Is(err, config.ErrInvalidSMTPConfig) {
+		slog.Warn("No smtp configuration loaded, will not render contact form")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := utils.SetImageCacheConfig(newCfg.Images.Cache, newCfg.Images.Force, imageCacheDir); err != nil {
+		slog.Warn("Failed to configure image cache", "error", err)
+	}
+	newCfg.Profile.ApplyImageCache()
+	content.PrefetchImages(newCfg.Profile.ContentTypes)
+
+	if err := newCfg.Profile.RenderHTML(); err != nil {
+		return nil, err
+	}
+	return newCfg, nil
+}
+
+// activate validates the snapshot of newCfg by rendering all enabled content
+// and parsing all templates into the registry and swaps it in if successful,
+// otherwise the current snapshot is kept, snapshotMu must be held
+func activate(newCfg *config.Config) error {
+	restoreTemplates := utils.ResetTemplates()
+	restoreContent := content.ResetRendered()
+	err := preloadTemplates(newCfg)
+	if err == nil {
+		err = content.RenderAll(newCfg.Profile.ContentTypes)
+	}
+	if err != nil {
+		restoreTemplates()
+		restoreContent()
+		return err
+	}
+
+	cfg = newCfg
+	messages.Compile(cfg.Profile.Email.Address)
+	return nil
+}
+
+// preloadTemplates parses all page, content and mail templates into the
+// template registry
+func preloadTemplates(cfg *config.Config) error {
+	pages, err := filepath.Glob(filepath.Join(appconfig.HTMLTemplatesPath(), "*.html"))
+	if err != nil {
+		return err
+	}
+	var sets []utils.TemplateSet
+	for _, page := range pages {
+		if page == appconfig.BaseTemplatePath() {
+			continue
+		}
+		sets = append(sets, utils.TemplateSet{
+			Name:  appconfig.BaseTemplateName,
+			Files: []string{appconfig.BaseTemplatePath(), page},
+		})
+	}
+	contentSets, err := content.TemplateSets()
+	if err != nil {
+		return err
+	}
+	sets = append(sets, contentSets...)
+	if cfg.RenderContact {
+		sets = append(sets, utils.TemplateSet{
+			Name:  strings.TrimSuffix(appconfig.MailTemplate, ".html"),
+			Files: []string{appconfig.MailTemplatePath()},
+		})
+	}
+	return utils.PreloadTemplates(sets...)
+}
+
+// reload loads and activates a new snapshot, it is rejected (keeping the
+// current snapshot) if anything fails to load, parse or render, it returns
+// true if the new snapshot is active
+func reload(configDir string, imageCacheDir string, changed []string) bool {
+	slog.Info("Detected changes, reloading", "files", changed)
+	start := time.Now()
+
+	if err := loadSnapshot(configDir, imageCacheDir); err != nil {
+		slog.Error("Reload rejected, keeping the previous snapshot", "error", err)
+		return false
+	}
+	slog.Info("Reload successful", "duration", time.Since(start))
+	return true
+}
+
+// watch reloads the snapshot whenever a file in the config, templates or
+// static directory changes until ctx is done, in development mode the
+// connected browsers are told to reload the page as well (only if the new
+// snapshot was accepted, the rejection is logged)
+func watch(ctx context.Context, interval time.Duration, configDir string, imageCacheDir string) {
+	w := watcher.New(interval, configDir, appconfig.TemplatesPath(), appconfig.StaticContentPath())
+	// downloads into the image cache must not trigger another reload
+	w.Ignore(utils.ImageCacheDir())
+	slog.Info("Watching for changes", "interval", interval)
+	w.Run(ctx, func(changed []string) {
+		if reload(configDir, imageCacheDir, changed) && utils.DevMode() {
+			reloader.notify()
+		}
+	})
+}
@@ -31,18 +31,26 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/mail"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	appconfig "github.com/bossm8/portfoli.go/config"
 
 	"github.com/bossm8/portfoli.go/handler"
+	"github.com/bossm8/portfoli.go/logging"
 	"github.com/bossm8/portfoli.go/messages"
+	"github.com/bossm8/portfoli.go/metrics"
 	"github.com/bossm8/portfoli.go/models"
 	"github.com/bossm8/portfoli.go/models/config"
 	"github.com/bossm8/portfoli.go/models/content"
@@ -54,61 +62,211 @@ import (
 var (
 	cfg         *config.Config
 	srvBasePath string
+	// middlewares registered with Use, applied after the built-in ones
+	middlewares []handler.Middleware
 )
 
-// StartServer will attempt to start and listen the server on the specified address
-func StartServer(addr string, basePath string, configDir string, imageCacheDir string) {
+// Use registers middlewares (e.g. for authentication or tracing) which are
+// applied to every request, it must be called before StartServer
+func Use(middleware ...handler.Middleware) {
+	middlewares = append(middlewares, middleware...)
+}
 
-	var err error
-	cfg, err = models.LoadConfiguration(configDir)
-	if err != nil && errors.Is(err, config.ErrInvalidSMTPConfig) {
-		log.Printf("[WARNING] No smtp configuration loaded, will not render contact form")
-		err = nil
-	} else if err != nil {
-		log.Fatalf("[WARNING] Aborting due to previous error")
-	}
+// Timeouts holds the timeouts applied to the http server
+type Timeouts struct {
+	// Read is the maximum duration for reading the entire request
+	Read time.Duration
+	// ReadHeader is the maximum duration for reading the request headers
+	ReadHeader time.Duration
+	// Write is the maximum duration before timing out writes of the response,
+	// it must be long enough to cover sending mails via the contact form
+	Write time.Duration
+	// Idle is the maximum duration to wait for the next request on keep-alive connections
+	Idle time.Duration
+	// Shutdown is the maximum duration to wait for in-flight requests to
+	// finish after a termination signal was received
+	Shutdown time.Duration
+}
+
+// StartServer will attempt to start and listen the server on the specified address
+// It blocks until SIGINT or SIGTERM is received and the server is shut down
+func StartServer(
+	addr string,
+	basePath string,
+	configDir string,
+	imageCacheDir string,
+	timeouts Timeouts,
+	tlsOpts TLSOptions,
+	watchInterval time.Duration,
+) {
 
 	srvBasePath = basePath
-	if err := utils.SetImageCacheConfig(cfg.Images.Cache, cfg.Images.Force, imageCacheDir); err != nil {
-		log.Printf("[WARNING] Failed to configure image cache: %s\n", err)
-	}
-	cfg.Profile.ApplyImageCache()
-	content.PrefetchImages(cfg.Profile.ContentTypes)
 	utils.Init(basePath)
 
-	if err := cfg.Profile.RenderHTML(); err != nil {
-		log.Fatalf("[WARNING] Aborting due to previous error")
+	if err := loadSnapshot(configDir, imageCacheDir); err != nil {
+		logging.Fatal("Aborting due to previous error", "error", err)
 	}
-	messages.Compile(cfg.Profile.Email.Address)
 
 	fs := http.FileServer(http.Dir(appconfig.StaticContentPath()))
 
 	_http := &handler.RegexHandler{}
 	_http.SetBasePath(basePath)
+	_http.Use(handler.AccessLog, metrics.Middleware, handler.Recover)
+	_http.Use(middlewares...)
+
+	_http.HandleMethods(`/favicon\.ico`, fs, http.MethodGet)
+	_http.HandleMethods("/static/.*", http.StripPrefix("/static", fs), http.MethodGet)
+	_http.HandleFuncMethods("/mail", sendMail, http.MethodPost)
+	_http.HandleFuncMethods("/healthz", serveHealth, http.MethodGet)
+	_http.HandleFuncMethods("/readyz", serveReady, http.MethodGet)
+	if utils.DevMode() {
+		_http.HandleFuncMethods(liveReloadEndpoint, serveLiveReload, http.MethodGet)
+	}
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Address == "" {
+		_http.HandleMethods("/metrics", metrics.Handler(), http.MethodGet)
+	}
+	_http.HandleFuncMethods("/(?P<status>"+messages.RoutingRegexString()+")", serveStatus, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods("/(?P<type>"+content.GetRoutingRegexString()+")", serveContent, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods("/tags", serveTagIndex, http.MethodGet).Use(withSnapshot)
+	// any type name is matched, so custom content types declared after the start have tag and detail pages too
+	_http.HandleFuncMethods("/(?P<type>[a-z0-9][a-z0-9-]*)/tags/(?P<tag>[a-z0-9][a-z0-9-]*)", serveContent, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods("/(?P<type>[a-z0-9][a-z0-9-]*)/(?P<slug>[a-z0-9][a-z0-9-]*)", serveCardDetail, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods(`/(?P<type>[a-z0-9][a-z0-9-]*)\.(?P<format>`+strings.Join(content.CitationFormats, "|")+")", serveCitations, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods("/?(?P<page>[^/]*)", serveGeneric, http.MethodGet).Use(withSnapshot)
+	_http.HandleFuncMethods(".*", serveNotFound, http.MethodGet)
+
+	srv := newServer(addr, _http, timeouts)
+	srv.RegisterOnShutdown(reloader.shutdown)
+	servers := []*http.Server{srv}
+
+	tlsCfg := cfg.Server.TLS
+	if tlsOpts.Cert != "" {
+		tlsCfg.Cert = tlsOpts.Cert
+	}
+	if tlsOpts.Key != "" {
+		tlsCfg.Key = tlsOpts.Key
+	}
+	if tlsOpts.RedirectAddr != "" {
+		tlsCfg.Redirect = tlsOpts.RedirectAddr
+	}
 
-	_http.Handle("/favicon.ico", fs)
-	_http.Handle("/static/", http.StripPrefix("/static", fs))
-	_http.HandleFunc("/mail", sendMail)
-	_http.HandleFunc("/"+messages.RoutingRegexString(), serveStatus)
-	_http.HandleFunc("/"+content.GetRoutingRegexString(), serveContent)
-	_http.HandleFunc(".*", serveGeneric)
+	if tlsCfg.Enabled() {
+		certs, err := newCertReloader(tlsCfg.Cert, tlsCfg.Key)
+		if err != nil {
+			logging.Fatal("Failed to load TLS certificate", "error", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go certs.watch(ctx)
 
-	log.Printf("[INFO] Listening on %s", addr)
-	err = http.ListenAndServe(addr, _http)
-	if err != nil {
-		log.Fatal(err)
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		if tlsCfg.Redirect != "" {
+			servers = append(servers, newServer(tlsCfg.Redirect, redirectHandler(addr, basePath), timeouts))
+		}
+	} else if tlsCfg.Cert != "" || tlsCfg.Key != "" {
+		logging.Fatal("TLS requires both a certificate and a key")
 	}
 
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Address != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+		servers = append(servers, newServer(cfg.Server.Metrics.Address, mux, timeouts))
+	}
+
+	if watchInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go watch(ctx, watchInterval, configDir, imageCacheDir)
+	}
+
+	if err := serve(timeouts.Shutdown, servers...); err != nil {
+		logging.Fatal("Server failed", "error", err)
+	}
+
+}
+
+func newServer(addr string, h http.Handler, timeouts Timeouts) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
+
+// serve runs all servers until one fails or a termination signal is received,
+// in the latter case the servers are shut down gracefully, waiting at most
+// shutdownTimeout for in-flight requests (e.g. contact mails) to finish
+// Servers with a TLSConfig are served via HTTPS
+func serve(shutdownTimeout time.Duration, servers ...*http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			if srv.TLSConfig != nil {
+				slog.Info("Listening", "address", srv.Addr, "scheme", "https")
+				errs <- srv.ListenAndServeTLS("", "")
+			} else {
+				slog.Info("Listening", "address", srv.Addr, "scheme", "http")
+				errs <- srv.ListenAndServe()
+			}
+		}(srv)
+	}
+
+	var serveErr error
+	select {
+	case serveErr = <-errs:
+	case <-ctx.Done():
+	}
+	// restore the default behaviour, so a second signal terminates immediately
+	stop()
+
+	slog.Info("Shutting down, waiting for open connections", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var shutdownErrs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			shutdownErrs = append(shutdownErrs, fmt.Errorf("graceful shutdown of %s failed: %w", srv.Addr, err))
+		}
+	}
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	if err := errors.Join(shutdownErrs...); err != nil {
+		return err
+	}
+	slog.Info("Server stopped")
+	return nil
 }
 
 func serveGeneric(w http.ResponseWriter, r *http.Request) {
-	tplName := "index"
-	if r.URL.Path != "/" && r.URL.Path != "" {
-		tplName = filepath.Base(r.URL.Path)
+	tplName := r.PathValue("page")
+	if tplName == "" {
+		tplName = "index"
+	}
+	// custom content types declared after the start are not part of the
+	// content route (which is built once)
+	if _, ok := content.Lookup(tplName); ok {
+		r.SetPathValue("type", tplName)
+		serveContent(w, r)
+		return
 	}
 	sendTemplate(w, r, tplName, nil, nil)
 }
 
+func serveNotFound(w http.ResponseWriter, r *http.Request) {
+	fail(w, r, messages.MsgNotFound)
+}
+
 func isContentEnabled(requestedContent string) bool {
 	enabled := false
 	for _, configuredContent := range cfg.Profile.ContentTypes {
@@ -122,14 +280,29 @@ func isContentEnabled(requestedContent string) bool {
 
 func serveContent(w http.ResponseWriter, r *http.Request) {
 
-	contentType := filepath.Base(r.URL.Path)
+	contentType := r.PathValue("type")
 	if !isContentEnabled(contentType) {
 		fail(w, r, messages.MsgNotFound)
 		return
 	}
 
-	data, err := content.GetRenderedContent(contentType)
-	if nil != err {
+	// the tag is either part of the path (like in the static build) or a query parameter
+	tag := r.PathValue("tag")
+	if tag == "" {
+		tag = r.URL.Query().Get("tag")
+	}
+
+	var data *content.ContentTemplateData
+	var err error
+	if tag != "" {
+		data, err = content.GetRenderedContentByTag(contentType, tag)
+	} else {
+		data, err = content.GetRenderedContent(contentType)
+	}
+	if errors.Is(err, content.ErrTagNotFound) {
+		fail(w, r, messages.MsgNotFound)
+		return
+	} else if nil != err {
 		fail(w, r, messages.MsgGeneric)
 		return
 	}
@@ -139,15 +312,73 @@ func serveContent(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func sendMail(w http.ResponseWriter, r *http.Request) {
+func serveCardDetail(w http.ResponseWriter, r *http.Request) {
 
-	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/"+appconfig.ContactTemplateName, http.StatusSeeOther)
+	contentType := r.PathValue("type")
+	if _, ok := content.Lookup(contentType); !ok || !isContentEnabled(contentType) {
+		fail(w, r, messages.MsgNotFound)
 		return
 	}
 
+	data, err := content.GetCardDetail(contentType, r.PathValue("slug"))
+	if errors.Is(err, content.ErrCardNotFound) {
+		fail(w, r, messages.MsgNotFound)
+		return
+	} else if err != nil {
+		fail(w, r, messages.MsgGeneric)
+		return
+	}
+
+	sendTemplate(w, r, appconfig.DetailTemplateName, data, nil)
+
+}
+
+// citationMediaTypes are the media types of the citation formats
+var citationMediaTypes = map[string]string{
+	content.CitationBibTeX:  "application/x-bibtex; charset=utf-8",
+	content.CitationCSLJSON: "application/vnd.citationstyles.csl+json",
+}
+
+func serveCitations(w http.ResponseWriter, r *http.Request) {
+
+	contentType, format := r.PathValue("type"), r.PathValue("format")
+	if _, ok := content.Lookup(contentType); !ok || !isContentEnabled(contentType) {
+		fail(w, r, messages.MsgNotFound)
+		return
+	}
+
+	data, err := content.GetCitations(contentType, format)
+	if errors.Is(err, content.ErrNoCitations) {
+		fail(w, r, messages.MsgNotFound)
+		return
+	} else if err != nil {
+		fail(w, r, messages.MsgGeneric)
+		return
+	}
+
+	w.Header().Set("Content-Type", citationMediaTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", contentType+"."+format))
+	w.Write(data)
+
+}
+
+func serveTagIndex(w http.ResponseWriter, r *http.Request) {
+
+	index, err := content.GetTagIndex(cfg.Profile.ContentTypes)
+	if err != nil {
+		fail(w, r, messages.MsgGeneric)
+		return
+	}
+
+	sendTemplate(w, r, appconfig.TagsTemplateName, index, nil)
+
+}
+
+func sendMail(w http.ResponseWriter, r *http.Request) {
+
 	if err := r.ParseForm(); err != nil {
-		log.Printf("[ERROR] Could not parse contact form: %s\n", err)
+		slog.Error("Could not parse contact form", "error", err)
+		metrics.ContactSubmission(metrics.ContactInvalidForm)
 		fail(w, r, messages.MsgContact)
 		return
 	}
@@ -170,22 +401,26 @@ func sendMail(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if addr, err = mail.ParseAddress(form["email"]); nil != err {
-		log.Println("[ERROR] Received invalid email address for contact form, will not send mail")
+		slog.Error("Received invalid email address for contact form, will not send mail")
+		metrics.ContactSubmission(metrics.ContactInvalidAddress)
 		fail(w, r, messages.MsgAddress)
 		return
 	}
 
+	cfg := currentConfig()
 	err = cfg.SMTP.SendMail(
 		cfg.Profile.Email.Address,
 		addr,
 		form["name"],
 		form["message"])
 	if nil != err {
+		metrics.ContactSubmission(metrics.ContactSMTPFailure)
 		fail(w, r, messages.MsgContact)
 		return
 	}
 
-	log.Printf("[INFO] Sent contact email to %s\n", cfg.Profile.Email)
+	slog.Info("Sent contact email", "to", cfg.Profile.Email)
+	metrics.ContactSubmission(metrics.ContactSuccess)
 
 	// redirect, so form gets cleared and a refresh does not trigger another send
 	success(w, r, messages.MsgContact)
@@ -197,7 +432,7 @@ func serveStatus(w http.ResponseWriter, r *http.Request) {
 	vals := r.URL.Query()
 	kind := vals.Get("kind")
 
-	status := filepath.Base(r.URL.Path)
+	status := r.PathValue("status")
 	msg := messages.Get(status, kind)
 
 	sendTemplate(w, r, appconfig.StatusTemplateName, msg, &msg.HttpStatus)
@@ -209,9 +444,9 @@ func abortWithStatusTplCheck(templateName string, w http.ResponseWriter, r *http
 		// This catches the case when the server cant find or has an error with the status template
 		// if this check is not made we end up having an infinite amount of requests
 		// because we would again be redirected to the status template
-		log.Printf(
-			"[WARNING] The template failed is the status template, aborting with %d\n",
-			messages.Get("fail", string(kind)).HttpStatus,
+		slog.Warn(
+			"The template failed is the status template, aborting",
+			"status", messages.Get("fail", string(kind)).HttpStatus,
 		)
 		w.WriteHeader(http.StatusInternalServerError)
 	} else {
@@ -223,7 +458,7 @@ func sendTemplate(w http.ResponseWriter, r *http.Request, templateName string, d
 
 	// catch errors which might occur by entering paths manually
 	if (!cfg.RenderContact && templateName == appconfig.ContactTemplateName) ||
-		(templateName == appconfig.StatusTemplateName && data == nil) ||
+		((templateName == appconfig.StatusTemplateName || templateName == appconfig.DetailTemplateName) && data == nil) ||
 		(templateName == appconfig.BaseTemplateName) {
 		fail(w, r, messages.MsgNotFound)
 		return
@@ -232,7 +467,7 @@ func sendTemplate(w http.ResponseWriter, r *http.Request, templateName string, d
 	htmlTpl := filepath.Join(appconfig.HTMLTemplatesPath(), templateName+".html")
 
 	if res, err := os.Stat(htmlTpl); os.IsNotExist(err) || res.IsDir() {
-		log.Printf("[ERROR] Could not find or read from %s\n", htmlTpl)
+		slog.Error("Could not find or read from template", "template", htmlTpl)
 		abortWithStatusTplCheck(templateName, w, r, messages.MsgNotFound)
 		return
 	}
@@ -253,14 +488,14 @@ func sendTemplate(w http.ResponseWriter, r *http.Request, templateName string, d
 	if nil != status && 100 <= *status {
 		w.WriteHeader(*status)
 	}
-	w.Write(resp)
+	w.Write(injectLiveReload(resp))
 
 }
 
 func fail(w http.ResponseWriter, r *http.Request, kind messages.MessageType) {
-	http.Redirect(w, r, fmt.Sprintf("%s%s?kind=%s", srvBasePath, messages.EndpointFail, kind), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("%s/%s?kind=%s", strings.TrimSuffix(srvBasePath, "/"), messages.EndpointFail, kind), http.StatusSeeOther)
 }
 
 func success(w http.ResponseWriter, r *http.Request, kind messages.MessageType) {
-	http.Redirect(w, r, fmt.Sprintf("%s%s?kind=%s", srvBasePath, messages.EndpointSuccess, kind), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("%s/%s?kind=%s", strings.TrimSuffix(srvBasePath, "/"), messages.EndpointSuccess, kind), http.StatusSeeOther)
 }
@@ -28,7 +28,49 @@
 
 package server
 
-import "testing"
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	appconfig "github.com/bossm8/portfoli.go/config"
+
+	"github.com/bossm8/portfoli.go/messages"
+	"github.com/bossm8/portfoli.go/models"
+	"github.com/bossm8/portfoli.go/models/content"
+	modelutils "github.com/bossm8/portfoli.go/models/utils"
+	"github.com/bossm8/portfoli.go/utils"
+)
+
+// loadExampleConfig loads the example configuration with the templates and
+// static content of the repository
+func loadExampleConfig(tb testing.TB) {
+	tb.Helper()
+
+	var err error
+	templates, static := "../templates", "../public"
+	appconfig.SetPaths(&templates, &static, nil)
+	utils.Init("/")
+	if cfg, err = models.LoadConfiguration("../examples/configs"); err != nil {
+		tb.Fatal(err)
+	}
+	messages.Compile(cfg.Profile.Email.Address)
+}
 
 func TestInvalidBaseConfig(t *testing.T) {
 	// should not run
@@ -45,3 +87,238 @@ func TestDisabledContentKind(t *testing.T) {
 func TestInvalidMailAddress(t *testing.T) {
 	// Should return BadRequest
 }
+
+func TestReadiness(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 fake.smtp ESMTP ready\r\n"))
+			conn.Close()
+		}
+	}()
+
+	loadExampleConfig(t)
+	cfg.SMTP.Host = "127.0.0.1"
+	cfg.SMTP.Port = l.Addr().(*net.TCPAddr).Port
+	cfg.Server.Readiness.SMTP = true
+
+	ready := func() (int, map[string]string) {
+		rr := httptest.NewRecorder()
+		serveReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var resp healthResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return rr.Code, resp.Checks
+	}
+
+	if status, checks := ready(); status != http.StatusOK {
+		t.Fatalf("Expected 200, got: %d (%v)", status, checks)
+	}
+
+	l.Close()
+	status, checks := ready()
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 with unreachable smtp server, got: %d", status)
+	}
+	if checks["smtp"] == checkOK {
+		t.Fatal("Expected smtp check to fail")
+	}
+
+}
+
+func TestRejectedReload(t *testing.T) {
+
+	loadExampleConfig(t)
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("../examples/configs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadSnapshot(dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	prevCfg, prevSortOrders, prevDateConfig := cfg, content.SortOrders(), content.CurrentDateConfig()
+	prevPolicy, prevStyle := modelutils.MarkdownOptions()
+
+	// the settings are applied while loading config.yml, the content fails afterwards
+	f, err := os.OpenFile(filepath.Join(dir, "config.yml"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString("\nsort:\n  projects:\n    by: name\ndates:\n  locale: de\nmarkdown:\n  policy: none\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "projects.yml"), []byte("projects: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadSnapshot(dir, ""); err == nil {
+		t.Fatal("Expected the reload to be rejected")
+	}
+	if cfg != prevCfg {
+		t.Error("Expected the previous configuration to be kept")
+	}
+	if orders := content.SortOrders(); !reflect.DeepEqual(orders, prevSortOrders) {
+		t.Errorf("Expected the previous sort orders to be restored, got: %v", orders)
+	}
+	if dates := content.CurrentDateConfig(); *dates != *prevDateConfig {
+		t.Errorf("Expected the previous date configuration to be restored, got: %+v", dates)
+	}
+	if policy, style := modelutils.MarkdownOptions(); policy != prevPolicy || style != prevStyle {
+		t.Errorf("Expected the previous markdown options to be restored, got: %s, %s", policy, style)
+	}
+
+}
+
+// writeCertificate writes a self-signed certificate for commonName and its key
+// to certFile and keyFile
+func writeCertificate(t *testing.T, certFile string, keyFile string, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, "first")
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commonName := func() string {
+		cert, _ := r.GetCertificate(nil)
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.Subject.CommonName
+	}
+	if name := commonName(); name != "first" {
+		t.Fatalf("Expected certificate 'first', got: %s", name)
+	}
+
+	r.maybeReload()
+	if name := commonName(); name != "first" {
+		t.Fatalf("Expected unchanged certificate to be kept, got: %s", name)
+	}
+
+	// replaced by files older than the loaded ones
+	writeCertificate(t, certFile, keyFile, "second")
+	older := r.modTime.Add(-time.Hour)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, older, older); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.maybeReload()
+	if name := commonName(); name != "second" {
+		t.Fatalf("Expected replaced certificate 'second', got: %s", name)
+	}
+
+	// an invalid key pair keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r.maybeReload()
+	if name := commonName(); name != "second" {
+		t.Fatalf("Expected previous certificate to be kept, got: %s", name)
+	}
+
+}
+
+func TestRedirectHandler(t *testing.T) {
+
+	for _, tc := range []struct {
+		tlsAddr  string
+		basePath string
+		target   string
+		expected string
+	}{
+		{":443", "/", "http://example.com/experience?tag=go", "https://example.com/experience?tag=go"},
+		{":8443", "/", "http://example.com:8080/", "https://example.com:8443/"},
+		{":443", "/portfolio", "http://example.com/portfolio/projects?a=b", "https://example.com/portfolio/projects?a=b"},
+		{":443", "/portfolio/", "http://example.com/portfolio", "https://example.com/portfolio"},
+		{":443", "/portfolio", "http://example.com/other?a=b", "https://example.com/portfolio?a=b"},
+	} {
+		rr := httptest.NewRecorder()
+		redirectHandler(tc.tlsAddr, tc.basePath).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if rr.Code != http.StatusMovedPermanently {
+			t.Fatalf("Expected 301 for %s, got: %d", tc.target, rr.Code)
+		}
+		if location := rr.Header().Get("Location"); location != tc.expected {
+			t.Fatalf("Expected redirect of %s to %s, got: %s", tc.target, tc.expected, location)
+		}
+	}
+
+}
+
+// BenchmarkServeContent compares the throughput of rendering a content page
+// when parsing templates and loading content per request (as in -dev mode)
+// with serving it from the template registry and content cache
+func BenchmarkServeContent(b *testing.B) {
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	loadExampleConfig(b)
+	defer utils.SetDevMode(false)
+
+	for _, bc := range []struct {
+		name string
+		dev  bool
+	}{
+		{"reparse", true},
+		{"cached", false},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			utils.SetDevMode(bc.dev)
+			req := httptest.NewRequest(http.MethodGet, "/experience", nil)
+			req.SetPathValue("type", "experience")
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rr := httptest.NewRecorder()
+				serveContent(rr, req)
+				if rr.Code != http.StatusOK {
+					b.Fatalf("Expected 200, got: %d", rr.Code)
+				}
+			}
+		})
+	}
+
+}
@@ -32,12 +32,13 @@ package static
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	appconfig "github.com/bossm8/portfoli.go/config"
 
+	"github.com/bossm8/portfoli.go/logging"
 	"github.com/bossm8/portfoli.go/messages"
 	"github.com/bossm8/portfoli.go/models"
 	"github.com/bossm8/portfoli.go/models/config"
@@ -55,11 +56,11 @@ func Build(srvBasePath string, configDir string, imageCacheDir string) {
 	var err error
 	cfg, err = models.LoadConfiguration(configDir)
 	if nil != err && !errors.Is(err, config.ErrInvalidSMTPConfig) {
-		log.Fatalf("[ERROR] Loading configuration failed: %s\n", err)
+		logging.Fatal("Loading configuration failed", "error", err)
 	}
 
 	if err := utils.SetImageCacheConfig(cfg.Images.Cache, cfg.Images.Force, imageCacheDir); err != nil {
-		log.Printf("[WARNING] Failed to configure image cache: %s\n", err)
+		slog.Warn("Failed to configure image cache", "error", err)
 	}
 	cfg.Profile.ApplyImageCache()
 	content.PrefetchImages(cfg.Profile.ContentTypes)
@@ -67,11 +68,12 @@ func Build(srvBasePath string, configDir string, imageCacheDir string) {
 	messages.Compile(cfg.Profile.Email.Address)
 
 	if err := cfg.Profile.RenderHTML(); err != nil {
-		log.Fatalf("[WARNING] Aborting due to previous error")
+		logging.Fatal("Aborting due to previous error")
 	}
 
 	buildGeneric()
 	buildContent()
+	buildTagIndex()
 	buildErrors()
 }
 
@@ -79,7 +81,7 @@ func Build(srvBasePath string, configDir string, imageCacheDir string) {
 func buildGeneric() {
 	templates, err := os.ReadDir("templates/html")
 	if nil != err {
-		log.Fatalf("[ERROR] Could not read template directory: %s\n", err)
+		logging.Fatal("Could not read template directory", "error", err)
 	}
 
 	for _, tpl := range templates {
@@ -100,7 +102,7 @@ func buildContent() {
 	for _, contentType := range cfg.Profile.ContentTypes {
 		data, err := content.GetRenderedContent(contentType)
 		if nil != err {
-			log.Fatalf("[ERROR] Rendering content %s: %s\n", contentType, err)
+			logging.Fatal("Rendering content failed", "content", contentType, "error", err)
 		}
 		data.Prev, data.Next = content.GetPagerLinks(contentType, cfg.Profile.ContentTypes)
 		build(
@@ -108,9 +110,70 @@ func buildContent() {
 			contentType+".html",
 			data,
 		)
+
+		tags, err := content.GetTags(contentType)
+		if nil != err {
+			logging.Fatal("Rendering tags failed", "content", contentType, "error", err)
+		}
+		for _, tag := range tags {
+			data, err := content.GetRenderedContentByTag(contentType, tag.Slug)
+			if nil != err {
+				logging.Fatal("Rendering tagged content failed", "content", contentType, "tag", tag.Name, "error", err)
+			}
+			data.Prev, data.Next = content.GetPagerLinks(contentType, cfg.Profile.ContentTypes)
+			build(
+				appconfig.ContentTemplateName+".html",
+				filepath.Join(contentType, "tags", tag.Slug+".html"),
+				data,
+			)
+		}
+
+		details, err := content.GetCardDetails(contentType)
+		if nil != err {
+			logging.Fatal("Rendering detail pages failed", "content", contentType, "error", err)
+		}
+		for _, detail := range details {
+			build(
+				appconfig.DetailTemplateName+".html",
+				filepath.Join(contentType, detail.Card.Base().GetSlug()+".html"),
+				detail,
+			)
+		}
+
+		buildCitations(contentType)
 	}
 }
 
+// buildCitations writes the citations of contentType in all formats, if it has any
+func buildCitations(contentType string) {
+	for _, format := range content.CitationFormats {
+		data, err := content.GetCitations(contentType, format)
+		if errors.Is(err, content.ErrNoCitations) {
+			return
+		} else if nil != err {
+			logging.Fatal("Rendering citations failed", "content", contentType, "format", format, "error", err)
+		}
+		outputFile := filepath.Join(appconfig.DistDir(), contentType+"."+format)
+		slog.Info("Writing citations", "output", outputFile)
+		if err := os.WriteFile(outputFile, data, 0664); nil != err {
+			logging.Fatal("Failed to write citations", "output", outputFile, "error", err)
+		}
+	}
+}
+
+// buildTagIndex builds the page listing the tags of all content types
+func buildTagIndex() {
+	index, err := content.GetTagIndex(cfg.Profile.ContentTypes)
+	if nil != err {
+		logging.Fatal("Rendering tag index failed", "error", err)
+	}
+	build(
+		appconfig.TagsTemplateName+".html",
+		appconfig.TagsTemplateName+".html",
+		index,
+	)
+}
+
 // buildError builds the error pages (which in case of static is 404 only)
 func buildErrors() {
 	msg := messages.Get(string(messages.EndpointFail), string(messages.MsgNotFound))
@@ -124,11 +187,11 @@ func buildErrors() {
 // build - generic method to build the template tplFileName to outputFileName
 // with data
 func build(tplFileName string, outputFileName string, data interface{}) {
-	log.Printf(
-		"[INFO] Rendering template %s to %s in %s\n",
-		tplFileName,
-		outputFileName,
-		appconfig.DistDir(),
+	slog.Info(
+		"Rendering template",
+		"template", tplFileName,
+		"output", outputFileName,
+		"dist", appconfig.DistDir(),
 	)
 
 	htmlTpl := filepath.Join(appconfig.HTMLTemplatesPath(), tplFileName)
@@ -147,12 +210,15 @@ func build(tplFileName string, outputFileName string, data interface{}) {
 		htmlTpl,
 	)
 	if nil != err {
-		log.Fatalf("[Error] Failed to render template: %s\n", err)
+		logging.Fatal("Failed to render template", "template", tplFileName, "error", err)
 	}
 
 	outputFile := filepath.Join(appconfig.DistDir(), outputFileName)
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0775); nil != err {
+		logging.Fatal("Failed to create output directory", "output", outputFile, "error", err)
+	}
 	if err := os.WriteFile(outputFile, resp, 0664); nil != err {
-		log.Fatalf("[ERROR] Failed to write template: %s\n", err)
+		logging.Fatal("Failed to write template", "output", outputFile, "error", err)
 	}
 
 }
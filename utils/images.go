@@ -33,7 +33,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -43,6 +43,7 @@ import (
 	"time"
 
 	appconfig "github.com/bossm8/portfoli.go/config"
+	"github.com/bossm8/portfoli.go/metrics"
 )
 
 var (
@@ -101,6 +102,30 @@ func SetImageCacheConfig(enabled bool, force bool, cacheDir string) error {
 	return nil
 }
 
+// ImageCacheState is the image cache configuration set by SetImageCacheConfig
+type ImageCacheState struct {
+	enabled bool
+	dir     string
+	public  string
+}
+
+// CurrentImageCache returns the current image cache configuration
+func CurrentImageCache() ImageCacheState {
+	return ImageCacheState{enabled: imageCacheEnabled, dir: imageCacheDir, public: imageCachePublic}
+}
+
+// RestoreImageCache restores a configuration returned by CurrentImageCache,
+// unlike SetImageCacheConfig the cache directory is not touched
+func RestoreImageCache(state ImageCacheState) {
+	imageCacheEnabled, imageCacheDir, imageCachePublic = state.enabled, state.dir, state.public
+}
+
+// ImageCacheDir returns the absolute path of the image cache directory, it
+// is empty if caching is disabled
+func ImageCacheDir() string {
+	return imageCacheDir
+}
+
 // MaybeCacheImage returns the cached local path for a remote image if enabled.
 func MaybeCacheImage(image string) string {
 	if !imageCacheEnabled {
@@ -119,12 +144,16 @@ func MaybeCacheImage(image string) string {
 	publicPath := filepath.ToSlash(filepath.Join(imageCachePublic, filename))
 
 	if _, err := os.Stat(localPath); err == nil {
+		metrics.ImageCacheLookup(metrics.ImageCacheHit)
 		return publicPath
 	}
+	metrics.ImageCacheLookup(metrics.ImageCacheMiss)
 	if err := downloadImage(image, localPath); err != nil {
-		log.Printf("[WARNING] Failed to cache image %s: %s\n", image, err)
+		slog.Warn("Failed to cache image", "image", image, "error", err)
+		metrics.ImageDownload(metrics.ImageDownloadFail)
 		return image
 	}
+	metrics.ImageDownload(metrics.ImageDownloadOK)
 	return publicPath
 }
 
@@ -159,7 +188,7 @@ func downloadImage(src string, dest string) error {
 	}
 	defer func() {
 		if err := os.Remove(tmp.Name()); err != nil && !os.IsNotExist(err) {
-			log.Printf("[WARNING] Failed to cleanup temp file %s: %s\n", tmp.Name(), err)
+			slog.Warn("Failed to cleanup temp file", "file", tmp.Name(), "error", err)
 		}
 	}()
 	if _, err := io.Copy(tmp, resp.Body); err != nil {
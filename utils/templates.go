@@ -0,0 +1,133 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package utils
+
+import (
+	"html/template"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+var (
+	// devMode disables all caches, so changes on templates and content are
+	// picked up on the next request
+	devMode bool
+
+	registryMu sync.RWMutex
+	// registry holds the parsed template sets by templateKey
+	registry = make(map[string]*template.Template)
+)
+
+// SetDevMode enables or disables the development mode, in which templates
+// are parsed (and content is loaded) for every request
+func SetDevMode(enabled bool) {
+	devMode = enabled
+	if enabled {
+		slog.Info("Development mode enabled, templates and content are reloaded on every request")
+	}
+	ResetTemplates()
+}
+
+// DevMode returns true if the development mode is enabled
+func DevMode() bool {
+	return devMode
+}
+
+// templateKey returns the key of the template set with the root template
+// name parsed from files
+func templateKey(name string, files []string) string {
+	return name + "\x00" + strings.Join(files, "\x00")
+}
+
+// ResetTemplates empties the template registry, so templates are parsed
+// again on the next use, the returned function restores the previous state
+func ResetTemplates() (restore func()) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	prev := registry
+	registry = make(map[string]*template.Template)
+	return func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		registry = prev
+	}
+}
+
+func parseTemplate(name string, files []string) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap).ParseFiles(files...)
+}
+
+// lookupTemplate returns the template set with the root template name
+// parsed from files. The set is parsed once and then served from the
+// registry, unless the development mode is enabled.
+func lookupTemplate(name string, files []string) (*template.Template, error) {
+	if devMode {
+		return parseTemplate(name, files)
+	}
+
+	key := templateKey(name, files)
+	registryMu.RLock()
+	tpl, ok := registry[key]
+	registryMu.RUnlock()
+	if ok {
+		return tpl, nil
+	}
+
+	tpl, err := parseTemplate(name, files)
+	if err != nil {
+		return nil, err
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[key] = tpl
+	return tpl, nil
+}
+
+// TemplateSet describes a set of template files rendered with the template Name
+type TemplateSet struct {
+	Name  string
+	Files []string
+}
+
+// PreloadTemplates parses the given template sets into the registry, so
+// parse errors surface on startup instead of on the first request
+func PreloadTemplates(sets ...TemplateSet) error {
+	checkFuncsInitializedOrAbort()
+	if devMode {
+		return nil
+	}
+	for _, set := range sets {
+		if _, err := lookupTemplate(set.Name, set.Files); err != nil {
+			return err
+		}
+	}
+	slog.Debug("Preloaded templates", "count", len(sets))
+	return nil
+}
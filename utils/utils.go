@@ -31,12 +31,17 @@ package utils
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
-	"log"
+	"io/fs"
+	"log/slog"
 	"net/url"
 	"path/filepath"
 	"strings"
 
+	"github.com/bossm8/portfoli.go/logging"
+	"github.com/bossm8/portfoli.go/metrics"
+
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -71,26 +76,34 @@ func Init(serverBasePath string) {
 	if !strings.HasSuffix(serverBasePath, "/") {
 		serverBasePath = serverBasePath + "/"
 	}
-	log.Printf("[INFO] Using %s as server base path\n", serverBasePath)
+	slog.Info("Using server base path", "path", serverBasePath)
 
 	funcMap = template.FuncMap{
 		"Title":    cases.Title(language.English).String,
 		"Assemble": assembleBasePath(serverBasePath),
 	}
+	// cached templates reference the previous functions
+	ResetTemplates()
+}
+
+// Assemble adds the server base path (see Init) to relative paths, the same
+// as the Assemble template function
+func Assemble(path string) string {
+	checkFuncsInitializedOrAbort()
+	return funcMap["Assemble"].(func(string) string)(path)
 }
 
 // checkFuncsInitializedOrAbort makes sure that the function maps were initialized
 // by calling Init, if not is will abort the program, as it is a programmer error
 func checkFuncsInitializedOrAbort() {
 	if funcMap == nil {
-		log.Fatal(
-			"[ERROR] Please call util.Init at least once before rendering a template",
-		)
+		logging.Fatal("Please call util.Init at least once before rendering a template")
 	}
 }
 
 // RenderTemplate renders the baseTemplate containing an optional childTemplate with the data
 // passed. (name) is passed to ExecuteTemplate
+// The parsed templates are cached (see PreloadTemplates and SetDevMode)
 func RenderTemplate(
 	tplName string,
 	data interface{},
@@ -102,14 +115,16 @@ func RenderTemplate(
 	var err error
 
 	// Title is used in templates to title case content kind names
-	if tpl, err = template.New(tplName).Funcs(funcMap).ParseFiles(templates...); nil != err {
-		log.Printf("[ERROR] Failed to parse templates: %s with error %s\n", templates, err)
+	if tpl, err = lookupTemplate(tplName, templates); nil != err {
+		slog.Error("Failed to parse templates", "templates", templates, "error", err)
+		metrics.TemplateRenderFailure(tplName)
 		return nil, err
 	}
 
 	resp := &bytes.Buffer{}
 	if err = tpl.ExecuteTemplate(resp, tplName, data); nil != err {
-		log.Printf("[ERROR] Failed to process template %s with error %s\n", tpl.Name(), err)
+		slog.Error("Failed to process template", "template", tpl.Name(), "error", err)
+		metrics.TemplateRenderFailure(tplName)
 		return nil, err
 	}
 
@@ -120,6 +135,26 @@ func RenderTemplate(
 	return pretty, nil
 }
 
+// CheckTemplates parses all html templates in dir and its subdirectories
+// without executing them, the errors of all failing ones are joined
+func CheckTemplates(dir string) error {
+	checkFuncsInitializedOrAbort()
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".html" {
+			return nil
+		}
+		if _, err := template.New(d.Name()).Funcs(funcMap).ParseFiles(path); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	return errors.Join(append(errs, err)...)
+}
+
 // ProcessHTMLContent takes a html template which could contain some template
 // pipelines and passes them through template.Execute.
 // This makes it possible to have e.g. Assemble in the content configs.
@@ -127,13 +162,13 @@ func ProcessHTMLContent(html *template.HTML) (*template.HTML, error) {
 	checkFuncsInitializedOrAbort()
 	tpl, err := template.New("html").Funcs(funcMap).Parse(string(*html))
 	if err != nil {
-		log.Printf("[ERROR Parsing html content %s\n", err)
+		slog.Error("Parsing html content failed", "error", err)
 		return nil, err
 	}
 
 	res := &bytes.Buffer{}
 	if err := tpl.Execute(res, nil); err != nil {
-		log.Printf("[ERROR] Executing template on html failed: %s\n", err)
+		slog.Error("Executing template on html failed", "error", err)
 		return nil, err
 	}
 	newHTML := template.HTML(res.String())
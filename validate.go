@@ -0,0 +1,149 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bossm8/portfoli.go/config"
+	modelconfig "github.com/bossm8/portfoli.go/models/config"
+	"github.com/bossm8/portfoli.go/models/content"
+	modelutils "github.com/bossm8/portfoli.go/models/utils"
+	"github.com/bossm8/portfoli.go/utils"
+)
+
+// runValidate checks config.yml, the content of all enabled content types
+// and the templates without starting a server. All problems are reported
+// with their position and result in a non-zero exit code.
+func runValidate(args []string) error {
+	fs := newFlagSet("validate", "Validate the configuration, content and templates.\n"+
+		"Problems are reported as file:line:column, the exit code is non-zero if any were found.")
+	paths := addPathFlags(fs)
+	strict := addStrictFlag(fs, true)
+	logs := addLogFlags(fs)
+	fs.Parse(args)
+
+	logs.setup(fs)
+	modelutils.SetStrict(*strict)
+
+	configDir := config.ConvertToAbsPath(paths.configDir)
+	config.SetPaths(paths.templatesDir, paths.staticDir, nil)
+	modelutils.SetYAMLDir(configDir)
+	utils.Init("/")
+
+	var problems []string
+	report := func(found []modelutils.Problem, err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, problem := range found {
+			problems = append(problems, problem.String())
+		}
+	}
+
+	cfg := &modelconfig.Config{}
+	found, err := modelutils.ValidateYAMLFile(modelconfig.ConfigFile, cfg)
+	overridden, envErr := modelconfig.ApplyEnvOverrides(cfg)
+	if envErr != nil {
+		problems = append(problems, envErr.Error())
+	}
+	if len(overridden) > 0 {
+		fmt.Println("Keys overridden from the environment:", strings.Join(overridden, ", "))
+		// the values in the yaml are not used for those keys
+		found = slices.DeleteFunc(found, func(p modelutils.Problem) bool {
+			return slices.ContainsFunc(overridden, func(key string) bool {
+				return p.Key == key || strings.HasPrefix(p.Key, key+".")
+			})
+		})
+	}
+	report(found, err)
+	if err := content.SetCustomTypes(cfg.CustomContent); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %s", modelconfig.ConfigFile, err))
+	}
+	// the content is checked with the configured markdown options, invalid
+	// ones are reported with config.yml already
+	if cfg.Markdown != nil {
+		if err := modelutils.SetMarkdownOptions(cfg.Markdown.Policy, cfg.Markdown.Style); err != nil {
+			slog.Debug("Validating markdown with the default options", "error", err)
+		}
+	}
+	if cfg.SMTP == nil {
+		slog.Warn("No smtp configuration found, the contact form will be disabled")
+	}
+
+	if cfg.Profile != nil {
+		var loaded []content.ContentConfig
+		for _, contentType := range cfg.Profile.ContentTypes {
+			// invalid content types are reported with config.yml already
+			if _, ok := content.Lookup(contentType); !ok {
+				continue
+			}
+			obj, err := content.New(contentType)
+			if err != nil {
+				return err
+			}
+			report(modelutils.ValidateYAMLFile(obj.ConfigName(), obj))
+			loaded = append(loaded, obj)
+		}
+		// the skills referenced by the cards must exist if skills are enabled
+		for _, obj := range loaded {
+			skills, ok := obj.(*content.SkillsConfig)
+			if !ok {
+				continue
+			}
+			for _, referrer := range loaded {
+				report(modelutils.LocateProblems(referrer.ConfigName(), content.CheckSkillRefs(referrer, skills)))
+			}
+		}
+	}
+
+	if err := utils.CheckTemplates(config.TemplatesPath()); err != nil {
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			for _, err := range joined.Unwrap() {
+				problems = append(problems, err.Error())
+			}
+		} else {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s)", len(problems))
+	}
+	fmt.Println("The configuration is valid")
+	return nil
+}
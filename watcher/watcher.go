@@ -0,0 +1,154 @@
+// Copyright (c) 2023, Boss Marco <bossm8+portfoligo@hotmail.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+// Package watcher implements a simple polling file system watcher, which
+// works the same on every platform and with files swapped via symlinks
+// (e.g. kubernetes config maps)
+package watcher
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher reports changes of files in a set of directories
+type Watcher struct {
+	dirs     []string
+	ignored  []string
+	interval time.Duration
+	state    map[string]fileState
+}
+
+// New returns a watcher checking the directories dirs (recursively) for
+// changes every interval
+func New(interval time.Duration, dirs ...string) *Watcher {
+	return &Watcher{
+		dirs:     dirs,
+		interval: interval,
+	}
+}
+
+// Ignore excludes the given paths (and everything below them) from watching
+func (w *Watcher) Ignore(paths ...string) {
+	for _, path := range paths {
+		if path != "" {
+			w.ignored = append(w.ignored, filepath.Clean(path))
+		}
+	}
+}
+
+func (w *Watcher) isIgnored(path string) bool {
+	for _, ignored := range w.ignored {
+		if path == ignored || strings.HasPrefix(path, ignored+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan returns the current state of all files in the watched directories
+func (w *Watcher) scan() map[string]fileState {
+	state := make(map[string]fileState)
+	for _, dir := range w.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// files might be removed while walking, they are reported on the next scan
+				return nil
+			}
+			if w.isIgnored(path) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			// follow symlinks, so swapped targets are detected
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil
+			}
+			state[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			slog.Warn("Failed to scan directory for changes", "dir", dir, "error", err)
+		}
+	}
+	return state
+}
+
+// changes compares the current state with the previous one and returns
+// the paths of all added, removed and modified files in sorted order
+func (w *Watcher) changes() []string {
+	current := w.scan()
+	var changed []string
+	for path, state := range current {
+		if prev, ok := w.state[path]; !ok || prev != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.state {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.state = current
+	slices.Sort(changed)
+	return changed
+}
+
+// Run checks for changes until ctx is done and calls onChange with the
+// paths of the changed files, the initial state is taken when called
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) {
+	w.state = w.scan()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed := w.changes(); len(changed) > 0 {
+				onChange(changed)
+			}
+		}
+	}
+}